@@ -0,0 +1,168 @@
+package main
+
+import (
+	"api-server/database"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// runCommand runs a migrate subcommand with its arguments.
+func runCommand(db *sql.DB, migrationsFS fs.FS, opts database.Options, command string, args []string) error {
+	switch command {
+	case "status":
+		return runStatus(db, migrationsFS, opts)
+	case "goto":
+		return runGoto(db, migrationsFS, opts, args)
+	case "down":
+		return runDown(db, migrationsFS, opts, args)
+	case "redo":
+		return database.RedoLastMigration(db, migrationsFS, opts)
+	case "baseline":
+		return runBaseline(db, migrationsFS, opts, args)
+	case "diff":
+		return runDiff(db, migrationsFS, opts)
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// runStatus prints a table of every migration and whether it is applied.
+func runStatus(db *sql.DB, migrationsFS fs.FS, opts database.Options) error {
+	statuses, err := database.GetMigrationStatus(db, migrationsFS, opts)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tMIGRATION\tSTATE\tAPPLIED AT\tDURATION\tAPPLIED BY")
+	for _, status := range statuses {
+		state, appliedAt, duration := string(status.State), "-", "-"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format(time.RFC3339)
+			duration = status.ExecutionTime.String()
+		}
+		if status.Baselined {
+			state += " (baselined)"
+			duration = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", status.Version, status.ID, state, appliedAt, duration, orDash(status.AppliedBy))
+	}
+	return w.Flush()
+}
+
+// runGoto migrates up or down to the version given as the only argument.
+func runGoto(db *sql.DB, migrationsFS fs.FS, opts database.Options, args []string) error {
+	version, err := versionArg("goto", args)
+	if err != nil {
+		return err
+	}
+	return database.MigrateTo(db, migrationsFS, version, opts)
+}
+
+// runBaseline marks the migrations up to the version given as the only argument as applied.
+func runBaseline(db *sql.DB, migrationsFS fs.FS, opts database.Options, args []string) error {
+	version, err := versionArg("baseline", args)
+	if err != nil {
+		return err
+	}
+	return database.BaselineMigrations(db, migrationsFS, version, opts)
+}
+
+// versionArg parses the version given as the only argument of a command.
+func versionArg(command string, args []string) (uint64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: %s <version>", command)
+	}
+	version, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", args[0])
+	}
+	return version, nil
+}
+
+// runDown rolls back the number of migrations given by -n.
+func runDown(db *sql.DB, migrationsFS fs.FS, opts database.Options, args []string) error {
+	flags := flag.NewFlagSet("down", flag.ExitOnError)
+	n := flags.Int("n", 1, "Number of migrations to roll back")
+	flags.Parse(args)
+	return database.RollbackMigrations(db, migrationsFS, *n, opts)
+}
+
+// runDiff prints how the live schema differs from the one the migrations describe,
+// failing when there is any difference so it can gate deployments.
+func runDiff(db *sql.DB, migrationsFS fs.FS, opts database.Options) error {
+	differences, err := database.DiffSchema(db, migrationsFS, opts)
+	if err != nil {
+		return err
+	}
+	if len(differences) == 0 {
+		fmt.Println("The schema matches the migrations")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OBJECT\tPROBLEM\tEXPECTED\tACTUAL")
+	for _, difference := range differences {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", difference.Object, difference.Problem, orDash(difference.Expected), orDash(difference.Actual))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return fmt.Errorf("the schema differs from the migrations in %d places", len(differences))
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// writeSchemaDump writes the canonical schema of the database to a file. The dump is written
+// to a temporary file next to it first, so a failed dump leaves the previous file intact.
+func writeSchemaDump(db *sql.DB, path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := database.DumpSchema(db, file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	// CreateTemp makes the file readable by its owner only
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// runCreate writes the files of a new migration named by the only argument.
+func runCreate(migrationsDir string, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	timestamp := flags.Bool("timestamp", false, "Version the migration with the current UTC time instead of the next sequence number")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: create [-timestamp] <name>")
+	}
+
+	migration, err := database.CreateMigration(migrationsDir, flags.Arg(0), *timestamp)
+	if err != nil {
+		return err
+	}
+	fmt.Println(filepath.Join(migrationsDir, migration.UpFile))
+	fmt.Println(filepath.Join(migrationsDir, migration.DownFile))
+	return nil
+}
@@ -3,17 +3,42 @@ package main
 import (
 	"api-server/config"
 	"api-server/database"
+	"api-server/database/migrations"
 	"flag"
+	"fmt"
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"time"
 )
 
 func main() {
 	// Define CLI flags
 	migrateUp := flag.Bool("up", false, "Apply all pending migrations")
 	migrateDown := flag.Bool("down", false, "Rollback the last migration")
+	repair := flag.Bool("repair", false, "Record the current checksums of applied migrations whose files were intentionally edited")
+	requireContiguous := flag.Bool("require-contiguous", false, "Fail when migration versions have gaps")
+	dir := flag.String("dir", "", "Read migrations from this directory instead of the ones embedded in the binary")
+	dryRun := flag.Bool("dry-run", false, "Print the SQL that would run instead of executing it")
+	out := flag.String("out", "", "Write the dry run SQL to this file as a single script (implies -dry-run)")
+	dumpSchema := flag.String("dump-schema", "", "After -up, write the canonical schema of the database to this file, e.g. database/schema.sql")
+	lockTimeout := flag.Duration("lock-timeout", time.Minute, "How long to wait for another process that is running migrations")
+	flag.Usage = usage
 	flag.Parse()
 
+	// Creating migration files does not need a database connection; they are written to disk
+	if flag.Arg(0) == "create" {
+		migrationsDir := *dir
+		if migrationsDir == "" {
+			migrationsDir = filepath.Join(".", "database", "migrations")
+		}
+		if err := runCreate(migrationsDir, flag.Args()[1:]); err != nil {
+			log.Fatalf("Error creating migration: %v", err)
+		}
+		return
+	}
+
 	// Load environment variables
 	if err := config.LoadEnv(); err != nil {
 		log.Fatalf("Error loading environment variables: %v", err)
@@ -26,29 +51,86 @@ func main() {
 	}
 	defer db.Close()
 
-	// Set the migrations directory
-	migrationsDir := filepath.Join(".", "database", "migrations")
+	// Use the embedded migrations unless a directory is given
+	var migrationsFS fs.FS = migrations.FS
+	if *dir != "" {
+		migrationsFS = os.DirFS(*dir)
+	}
+	opts := database.Options{LockTimeout: *lockTimeout, RequireContiguous: *requireContiguous, DryRun: *dryRun}
+	if *out != "" {
+		script, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Error creating %s: %v", *out, err)
+		}
+		defer script.Close()
+		opts.DryRun = true
+		opts.Output = script
+	}
+
+	// Run a subcommand if one is given
+	if flag.NArg() > 0 {
+		if err := runCommand(db, migrationsFS, opts, flag.Arg(0), flag.Args()[1:]); err != nil {
+			log.Fatalf("Error running %s: %v", flag.Arg(0), err)
+		}
+		return
+	}
+
+	// Accept edits to applied migrations if the 'repair' flag is set
+	if *repair {
+		if opts.DryRun {
+			log.Fatal("-repair cannot be combined with -dry-run")
+		}
+		log.Println("Repairing migration checksums...")
+		if err := database.RepairChecksums(db, migrationsFS, opts); err != nil {
+			log.Fatalf("Error repairing migration checksums: %v", err)
+		}
+		return
+	}
 
 	// Apply migrations if the 'up' flag is set
 	if *migrateUp {
 		log.Println("Applying migrations...")
-		if err := database.ApplyMigrations(db, migrationsDir); err != nil {
+		if err := database.ApplyMigrations(db, migrationsFS, opts); err != nil {
 			log.Fatalf("Error applying migrations: %v", err)
 		}
-		log.Println("Migrations applied successfully")
+		if !opts.DryRun {
+			log.Println("Migrations applied successfully")
+		}
+		if *dumpSchema != "" && !opts.DryRun {
+			if err := writeSchemaDump(db, *dumpSchema); err != nil {
+				log.Fatalf("Error dumping schema: %v", err)
+			}
+			log.Printf("Schema written to %s", *dumpSchema)
+		}
 		return
 	}
 
 	// Rollback the last migration if the 'down' flag is set
 	if *migrateDown {
 		log.Println("Rolling back the last migration...")
-		if err := database.RollbackLastMigration(db, migrationsDir); err != nil {
+		if err := database.RollbackLastMigration(db, migrationsFS, opts); err != nil {
 			log.Fatalf("Error rolling back the migration: %v", err)
 		}
-		log.Println("Migration rolled back successfully")
+		if !opts.DryRun {
+			log.Println("Migration rolled back successfully")
+		}
 		return
 	}
 
 	// If no flags are provided, print usage
 	flag.Usage()
 }
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	fmt.Fprintln(out, "  create [-timestamp] <name>  Write an empty pair of up and down files for a new migration")
+	fmt.Fprintln(out, "  status                      Show applied, pending and missing migrations")
+	fmt.Fprintln(out, "  goto <version>              Migrate up or down until exactly the migrations up to <version> are applied")
+	fmt.Fprintln(out, "  down [-n N]                 Roll back the last N migrations (default 1)")
+	fmt.Fprintln(out, "  redo                        Roll back the last migration and apply it again")
+	fmt.Fprintln(out, "  baseline <version>          Mark the migrations up to <version> as applied without running them")
+	fmt.Fprintln(out, "  diff                        Compare the live schema with the schema the migrations describe")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
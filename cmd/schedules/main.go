@@ -36,21 +36,46 @@ func main() {
 	select {}
 }
 
-// registerSchedules registers all cron jobs
+// registerSchedules adds every registered job that the configuration enables. Jobs run through a
+// schedules.Runner, so each scheduled run executes once even with several instances of this command,
+// and runs abandoned by a stopped instance are caught up in the background.
 func registerSchedules(c *cron.Cron, db *sql.DB) {
-	// Run ExampleTask every minute
-	_, err := c.AddFunc("*/1 * * * * *", func() {
-		schedules.ExampleTask(db) // Call the decoupled task from schedules package
-	})
+	var names []string
+	for _, job := range schedules.Jobs() {
+		names = append(names, job.Name)
+	}
+	cfg, err := config.LoadSchedulesConfig(names)
 	if err != nil {
-		log.Fatalf("Error scheduling ExampleTask: %v", err)
+		log.Fatalf("Error loading schedules configuration: %v", err)
 	}
 
-	// Run DailyCleanupTask every day at midnight
-	_, err = c.AddFunc("0 0 * * * *", func() {
-		schedules.DailyCleanupTask(db)
-	})
+	jobs, err := schedules.Configure(cfg)
 	if err != nil {
-		log.Fatalf("Error scheduling DailyCleanupTask: %v", err)
+		log.Fatalf("Error configuring schedules: %v", err)
 	}
+
+	runner := schedules.NewRunner(db)
+	var enabled []schedules.ScheduledJob
+	for _, job := range jobs {
+		if !job.Enabled {
+			log.Printf("Job %s is disabled", job.Name)
+			continue
+		}
+
+		// The entry's Prev is the time the run was scheduled for, the same on every instance
+		var id cron.EntryID
+		id, err := c.AddFunc(job.Spec(), func() { runner.Run(job, c.Entry(id).Prev) })
+		if err != nil {
+			log.Fatalf("Error scheduling job %s with %q: %v", job.Name, job.Spec(), err)
+		}
+		log.Printf("Job %s scheduled: %s", job.Name, job.Spec())
+		enabled = append(enabled, job)
+	}
+
+	// Catch up on runs abandoned by instances that stopped mid-task
+	go func() {
+		for _, job := range enabled {
+			runner.Recover(job)
+		}
+	}()
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"api-server/config"
+	"api-server/database"
+	"api-server/database/seeds"
+	"flag"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	// Define CLI flags
+	env := flag.String("env", "dev", "Environment to seed: "+strings.Join(database.SeedEnvironments, ", "))
+	dir := flag.String("dir", "", "Read seeds from this directory instead of the ones embedded in the binary")
+	lockTimeout := flag.Duration("lock-timeout", time.Minute, "How long to wait for another process that is running migrations or seeds")
+	flag.Parse()
+
+	// Load environment variables
+	if err := config.LoadEnv(); err != nil {
+		log.Fatalf("Error loading environment variables: %v", err)
+	}
+
+	// The dev and test fixtures create admins with passwords published in this repository
+	if (*env == "dev" || *env == "test") && os.Getenv("ALLOW_DEV_SEEDS") != "true" {
+		log.Fatalf("Refusing to load the %s seeds, which create admins with known passwords; set ALLOW_DEV_SEEDS=true to load them", *env)
+	}
+
+	// Initialize the database connection
+	db, err := config.InitDB()
+	if err != nil {
+		log.Fatalf("Error initializing database: %v", err)
+	}
+	defer db.Close()
+
+	// Use the embedded seeds unless a directory is given
+	var seedsFS fs.FS = seeds.FS
+	if *dir != "" {
+		seedsFS = os.DirFS(*dir)
+	}
+
+	log.Printf("Seeding the %s environment...", *env)
+	if err := database.ApplySeeds(db, seedsFS, *env, database.Options{LockTimeout: *lockTimeout}); err != nil {
+		log.Fatalf("Error applying seeds: %v", err)
+	}
+}
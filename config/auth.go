@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// AuthConfig holds the settings used to issue and verify access and refresh tokens.
+type AuthConfig struct {
+	SigningKey      []byte
+	Algorithm       string
+	Issuer          string
+	Audience        string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// LoadAuthConfig reads the JWT settings from the environment.
+func LoadAuthConfig() (*AuthConfig, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("JWT_SECRET must be set")
+	}
+
+	algorithm := os.Getenv("JWT_ALGORITHM")
+	if algorithm == "" {
+		algorithm = "HS256" // default signing algorithm
+	}
+	switch algorithm {
+	case "HS256", "HS384", "HS512":
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM: %s", algorithm)
+	}
+
+	accessTokenTTL, err := durationFromEnv("JWT_ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenTTL, err := durationFromEnv("JWT_REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthConfig{
+		SigningKey:      []byte(secret),
+		Algorithm:       algorithm,
+		Issuer:          os.Getenv("JWT_ISSUER"),
+		Audience:        os.Getenv("JWT_AUDIENCE"),
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+	}, nil
+}
+
+// durationFromEnv parses a Go duration (e.g. "15m") from the environment, falling back to def when unset.
+func durationFromEnv(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive", key)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+// LoadSoftDeleteRetention returns how long soft deleted rows are kept before they are purged.
+func LoadSoftDeleteRetention() (time.Duration, error) {
+	return durationFromEnv("SOFT_DELETE_RETENTION", 30*24*time.Hour)
+}
+
+// LoadScheduleRunRetention returns how long the records of claimed schedule runs are kept.
+func LoadScheduleRunRetention() (time.Duration, error) {
+	return durationFromEnv("SCHEDULE_RUN_RETENTION", 24*time.Hour)
+}
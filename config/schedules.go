@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SchedulesConfig overrides the registered settings of scheduled jobs.
+type SchedulesConfig struct {
+	// TimeZone is the IANA time zone cron expressions are evaluated in, unless a job sets its own.
+	// Empty means the local time zone of the host.
+	TimeZone string `json:"time_zone"`
+	// Jobs holds the overrides of individual jobs, keyed by job name.
+	Jobs map[string]JobConfig `json:"jobs"`
+}
+
+// JobConfig overrides the settings of one scheduled job. Unset fields keep the registered defaults.
+type JobConfig struct {
+	Enabled  *bool  `json:"enabled"`
+	Schedule string `json:"schedule"`
+	TimeZone string `json:"time_zone"`
+}
+
+// LoadSchedulesConfig reads the job settings from the JSON file named by SCHEDULES_CONFIG, if set,
+// and then applies environment overrides on top: SCHEDULES_TIME_ZONE for the default time zone and
+// SCHEDULE_<JOB>_ENABLED, SCHEDULE_<JOB>_CRON and SCHEDULE_<JOB>_TZ for a job, where <JOB> is the
+// upper-cased job name, e.g. SCHEDULE_DAILY_CLEANUP_CRON. Only the names of jobs are matched, so
+// other SCHEDULE_* variables are logged and ignored. Unknown keys in the file are an error.
+func LoadSchedulesConfig(jobs []string) (*SchedulesConfig, error) {
+	cfg := &SchedulesConfig{Jobs: make(map[string]JobConfig)}
+
+	if path := os.Getenv("SCHEDULES_CONFIG"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading SCHEDULES_CONFIG: %w", err)
+		}
+		defer file.Close()
+
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("invalid SCHEDULES_CONFIG %s: %w", path, err)
+		}
+		if cfg.Jobs == nil {
+			cfg.Jobs = make(map[string]JobConfig)
+		}
+	}
+
+	if timeZone := os.Getenv("SCHEDULES_TIME_ZONE"); timeZone != "" {
+		cfg.TimeZone = timeZone
+	}
+
+	known := make(map[string]bool, len(jobs))
+	for _, name := range jobs {
+		known[name] = true
+	}
+
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		rest, ok := strings.CutPrefix(key, "SCHEDULE_")
+		if !ok {
+			continue
+		}
+
+		var field string
+		for _, suffix := range []string{"_ENABLED", "_CRON", "_TZ"} {
+			if name, found := strings.CutSuffix(rest, suffix); found && name != "" {
+				rest, field = name, suffix
+				break
+			}
+		}
+		if field == "" {
+			continue
+		}
+
+		name := strings.ToLower(rest)
+		if !known[name] {
+			log.Printf("Ignoring %s: there is no job named %s", key, name)
+			continue
+		}
+
+		job := cfg.Jobs[name]
+		switch field {
+		case "_ENABLED":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			job.Enabled = &enabled
+		case "_CRON":
+			job.Schedule = value
+		case "_TZ":
+			job.TimeZone = value
+		}
+		cfg.Jobs[name] = job
+	}
+
+	return cfg, nil
+}
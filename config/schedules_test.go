@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// writeSchedulesConfig writes a configuration file and points SCHEDULES_CONFIG at it.
+func writeSchedulesConfig(t *testing.T, contents string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schedules.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SCHEDULES_CONFIG", path)
+}
+
+func TestLoadSchedulesConfig(t *testing.T) {
+	jobs := []string{"example", "daily_cleanup", "purge_revoked_tokens"}
+
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want *SchedulesConfig
+	}{
+		{
+			name: "nothing configured",
+			want: &SchedulesConfig{Jobs: map[string]JobConfig{}},
+		},
+		{
+			name: "file only",
+			file: `{"time_zone": "Europe/Berlin", "jobs": {"example": {"enabled": false}, "daily_cleanup": {"schedule": "0 30 2 * * *", "time_zone": "UTC"}}}`,
+			want: &SchedulesConfig{TimeZone: "Europe/Berlin", Jobs: map[string]JobConfig{
+				"example":       {Enabled: boolPtr(false)},
+				"daily_cleanup": {Schedule: "0 30 2 * * *", TimeZone: "UTC"},
+			}},
+		},
+		{
+			name: "environment overrides the file",
+			file: `{"time_zone": "Europe/Berlin", "jobs": {"daily_cleanup": {"schedule": "0 30 2 * * *", "time_zone": "UTC"}}}`,
+			env: map[string]string{
+				"SCHEDULES_TIME_ZONE":              "America/New_York",
+				"SCHEDULE_DAILY_CLEANUP_CRON":      "0 0 3 * * *",
+				"SCHEDULE_EXAMPLE_ENABLED":         "true",
+				"SCHEDULE_PURGE_REVOKED_TOKENS_TZ": "Asia/Tokyo",
+			},
+			want: &SchedulesConfig{TimeZone: "America/New_York", Jobs: map[string]JobConfig{
+				"example":              {Enabled: boolPtr(true)},
+				"daily_cleanup":        {Schedule: "0 0 3 * * *", TimeZone: "UTC"},
+				"purge_revoked_tokens": {TimeZone: "Asia/Tokyo"},
+			}},
+		},
+		{
+			name: "variables of unknown jobs are ignored",
+			env: map[string]string{
+				"SCHEDULE_RUN_TZ":        "UTC",
+				"SCHEDULE_RUN_RETENTION": "24h",
+				"SCHEDULE__CRON":         "* * * * * *",
+			},
+			want: &SchedulesConfig{Jobs: map[string]JobConfig{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SCHEDULES_CONFIG", "")
+			t.Setenv("SCHEDULES_TIME_ZONE", "")
+			if tt.file != "" {
+				writeSchedulesConfig(t, tt.file)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := LoadSchedulesConfig(jobs)
+			if err != nil {
+				t.Fatalf("LoadSchedulesConfig returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadSchedulesConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchedulesConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "misspelled key",
+			file: `{"jobs": {"daily_cleanup": {"schedul": "0 0 1 * * *"}}}`,
+			want: `unknown field "schedul"`,
+		},
+		{
+			name: "malformed file",
+			file: `{"jobs": `,
+			want: "invalid SCHEDULES_CONFIG",
+		},
+		{
+			name: "invalid boolean",
+			env:  map[string]string{"SCHEDULE_EXAMPLE_ENABLED": "sometimes"},
+			want: "invalid SCHEDULE_EXAMPLE_ENABLED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SCHEDULES_CONFIG", "")
+			if tt.file != "" {
+				writeSchedulesConfig(t, tt.file)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := LoadSchedulesConfig([]string{"example", "daily_cleanup"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadSchedulesConfig error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSchedulesConfigMissingFile(t *testing.T) {
+	t.Setenv("SCHEDULES_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := LoadSchedulesConfig(nil); err == nil {
+		t.Error("LoadSchedulesConfig succeeded with a missing file")
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"api-server/services"
+	"api-server/validators"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	roles *services.RoleService
+	users *services.UserService
+	items *services.ItemService
+}
+
+func NewAdminController(db *sql.DB) *AdminController {
+	return &AdminController{
+		roles: services.NewRoleService(db),
+		users: services.NewUserService(db),
+		items: services.NewItemService(db),
+	}
+}
+
+// GetRoles lists every role with its permissions
+func (ac *AdminController) GetRoles(c *gin.Context) {
+	roles, err := ac.roles.GetAllRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetUserRoles lists the roles assigned to a user
+func (ac *AdminController) GetUserRoles(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	roles, err := ac.roles.GetUserRoles(userID)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "roles": roles})
+}
+
+// AssignRole assigns a role to a user
+func (ac *AdminController) AssignRole(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input validators.AssignRoleValidator
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	roles, err := ac.roles.AssignRole(userID, input.Role)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "roles": roles})
+}
+
+// RemoveRole removes a role from a user
+func (ac *AdminController) RemoveRole(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	roles, err := ac.roles.RemoveRole(userID, c.Param("role"))
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "roles": roles})
+}
+
+// DeleteUser soft deletes a user
+func (ac *AdminController) DeleteUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := ac.users.DeleteUser(userID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreUser restores a soft deleted user
+func (ac *AdminController) RestoreUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := ac.users.RestoreUser(userID)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// RestoreItem restores a soft deleted item
+func (ac *AdminController) RestoreItem(c *gin.Context) {
+	item, err := ac.items.RestoreItem(c.Param("id"))
+	if err != nil {
+		respondItemError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// userIDParam parses the :id path parameter, responding with 404 when it is not a valid ID.
+func userIDParam(c *gin.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+		return 0, false
+	}
+	return userID, true
+}
+
+func respondAdminError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrRoleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -2,10 +2,14 @@ package controllers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"api-server/middlewares"
 	"api-server/models"
+	"api-server/services"
 	"api-server/utils"
 	"api-server/validators"
 
@@ -14,12 +18,13 @@ import (
 )
 
 type AuthController struct {
-	db *sql.DB
+	db     *sql.DB
+	tokens *services.TokenService
 }
 
-// NewAuthController initializes AuthController with DB connection.
-func NewAuthController(db *sql.DB) *AuthController {
-	return &AuthController{db: db}
+// NewAuthController initializes AuthController with DB connection and token service.
+func NewAuthController(db *sql.DB, tokens *services.TokenService) *AuthController {
+	return &AuthController{db: db, tokens: tokens}
 }
 
 // Register handles user registration
@@ -48,9 +53,16 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	// Create the user
+	// Create the user together with the default role, so no user is left without a role
+	tx, err := ac.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		return
+	}
+	defer tx.Rollback()
+
 	var newUser models.User
-	err = ac.db.QueryRow(
+	err = tx.QueryRow(
 		`INSERT INTO users (username, email, password_hash, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, username, email, created_at`,
 		input.Username, input.Email, hashedPassword, time.Now(), time.Now(),
 	).Scan(&newUser.ID, &newUser.Username, &newUser.Email, &newUser.CreatedAt)
@@ -59,6 +71,26 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
+	result, err := tx.Exec(
+		`INSERT INTO user_roles (user_id, role_id) SELECT $1, id FROM roles WHERE name = $2`,
+		newUser.ID, models.DefaultRole,
+	)
+	if err == nil {
+		if assigned, _ := result.RowsAffected(); assigned == 0 {
+			err = errors.New("default role does not exist")
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign default role"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		return
+	}
+	newUser.Roles = []string{models.DefaultRole}
+
 	c.JSON(http.StatusCreated, newUser)
 }
 
@@ -75,7 +107,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 	// Get the user by email
 	var user models.User
-	err := ac.db.QueryRow(`SELECT id, username, email, password_hash FROM users WHERE email = $1`, input.Email).
+	err := ac.db.QueryRow(`SELECT id, username, email, password_hash FROM users WHERE email = $1 AND deleted_at IS NULL`, input.Email).
 		Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
@@ -88,5 +120,93 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "login successful", "user": user})
+	// Start a new session for the device
+	pair, err := ac.tokens.IssueTokenPair(&user, deviceName(c, input.Device))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue tokens"})
+		return
+	}
+
+	response := tokenResponse(pair)
+	response["message"] = "login successful"
+	response["user"] = user
+	c.JSON(http.StatusOK, response)
+}
+
+// Refresh exchanges a refresh token for a new token pair, rotating the refresh token
+func (ac *AuthController) Refresh(c *gin.Context) {
+	var input validators.RefreshTokenValidator
+
+	// Bind and validate the input JSON
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	pair, err := ac.tokens.Refresh(input.RefreshToken, input.Device)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse(pair))
+}
+
+// Logout revokes the current access token and its refresh token family
+func (ac *AuthController) Logout(c *gin.Context) {
+	claims, ok := middlewares.CurrentClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
+	if err := ac.tokens.Logout(claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logout successful"})
+}
+
+// LogoutAll revokes every session of the current user
+func (ac *AuthController) LogoutAll(c *gin.Context) {
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
+	if err := ac.tokens.LogoutAll(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out of all sessions"})
+}
+
+// tokenResponse builds the JSON body returned for a newly issued token pair.
+func tokenResponse(pair *services.TokenPair) gin.H {
+	return gin.H{
+		"access_token":             pair.AccessToken,
+		"token_type":               "Bearer",
+		"expires_in":               int64(time.Until(pair.AccessTokenExpiresAt).Seconds()),
+		"refresh_token":            pair.RefreshToken,
+		"refresh_token_expires_in": int64(time.Until(pair.RefreshTokenExpiresAt).Seconds()),
+	}
+}
+
+// deviceName returns the client supplied device name, falling back to the User-Agent header.
+// It is cut to the 255 characters the refresh_tokens column holds, on a character boundary.
+func deviceName(c *gin.Context, device string) string {
+	if device == "" {
+		device = strings.ToValidUTF8(c.GetHeader("User-Agent"), "\uFFFD")
+	}
+	if runes := []rune(device); len(runes) > 255 {
+		device = string(runes[:255])
+	}
+	return device
 }
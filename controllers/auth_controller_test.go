@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeviceName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		device    string
+		userAgent string
+		want      string
+	}{
+		{"device given", "Work laptop", "curl/8.0", "Work laptop"},
+		{"user agent fallback", "", "curl/8.0", "curl/8.0"},
+		{"multi-byte device within limit", strings.Repeat("é", 200), "", strings.Repeat("é", 200)},
+		{"long multi-byte user agent", "", strings.Repeat("é", 300), strings.Repeat("é", 255)},
+		{"invalid user agent bytes", "", "agent\xff", "agent�"},
+	}
+
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/auth/login", nil)
+		c.Request.Header.Set("User-Agent", tt.userAgent)
+
+		got := deviceName(c, tt.device)
+		if got != tt.want {
+			t.Errorf("%s: deviceName() = %q, want %q", tt.name, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: deviceName() returned invalid UTF-8 %q", tt.name, got)
+		}
+	}
+}
@@ -2,14 +2,22 @@ package controllers
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 
+	"api-server/middlewares"
+	"api-server/models"
 	"api-server/services"
+	"api-server/utils"
 	"api-server/validators"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// mergePatchContentType is the media type of JSON Merge Patch documents (RFC 7396).
+const mergePatchContentType = "application/merge-patch+json"
+
 type ItemController struct {
 	service *services.ItemService
 }
@@ -21,35 +29,239 @@ func NewItemController(db *sql.DB) *ItemController {
 }
 
 func (ic *ItemController) GetItems(c *gin.Context) {
-	items, err := ic.service.GetAllItems()
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
+	var query validators.ListItemsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	page, err := ic.service.ListItems(user, services.ListItemsParams{
+		Limit:        query.Limit,
+		Offset:       query.Offset,
+		Cursor:       query.Cursor,
+		Sort:         query.Sort,
+		Name:         query.Name,
+		NamePrefix:   query.NamePrefix,
+		NameContains: query.NameContains,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondItemError(c, err)
+		return
+	}
+
+	setPaginationLinks(c, page.Pagination)
+	c.JSON(http.StatusOK, page)
+}
+
+// SearchItems runs a ranked full-text search over item names
+func (ic *ItemController) SearchItems(c *gin.Context) {
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
 		return
 	}
-	c.JSON(http.StatusOK, items)
+
+	var query validators.SearchItemsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	page, err := ic.service.SearchItems(user, services.SearchItemsParams{
+		Query:  query.Query,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+		Cursor: query.Cursor,
+	})
+	if err != nil {
+		respondItemError(c, err)
+		return
+	}
+
+	setPaginationLinks(c, page.Pagination)
+	c.JSON(http.StatusOK, page)
 }
 
 func (ic *ItemController) GetItem(c *gin.Context) {
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
 	id := c.Param("id")
-	item, err := ic.service.GetItemByID(id)
+	item, err := ic.service.GetItemByID(user, id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		respondItemError(c, err)
+		return
+	}
+
+	etag := utils.FormatETag(item.Version)
+	c.Header("ETag", etag)
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" && utils.MatchETag(ifNoneMatch, etag, true) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 	c.JSON(http.StatusOK, item)
 }
 
 func (ic *ItemController) CreateItem(c *gin.Context) {
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
 	var input validators.CreateItemInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	item, err := ic.service.CreateItem(input.Name)
+	item, err := ic.service.CreateItem(user, input.Name)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.Header("ETag", utils.FormatETag(item.Version))
 	c.JSON(http.StatusCreated, item)
 }
+
+func (ic *ItemController) UpdateItem(c *gin.Context) {
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
+	current, ok := ic.currentItemForWrite(c, user)
+	if !ok {
+		return
+	}
+
+	var input validators.UpdateItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	item, err := ic.service.UpdateItem(user, current.ID, current.Version, input.Name)
+	if err != nil {
+		respondItemError(c, err)
+		return
+	}
+	c.Header("ETag", utils.FormatETag(item.Version))
+	c.JSON(http.StatusOK, item)
+}
+
+// PatchItem applies a JSON Merge Patch (RFC 7396) to an item
+func (ic *ItemController) PatchItem(c *gin.Context) {
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
+	if contentType := c.ContentType(); contentType != mergePatchContentType && contentType != gin.MIMEJSON {
+		c.Header("Accept-Patch", mergePatchContentType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "PATCH requires a " + mergePatchContentType + " body"})
+		return
+	}
+
+	current, ok := ic.currentItemForWrite(c, user)
+	if !ok {
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	patched, err := ic.service.MergeItemPatch(current, patch)
+	if err != nil {
+		respondItemError(c, err)
+		return
+	}
+
+	// Validate the patched document like a regular request body
+	var input validators.PatchItemInput
+	if err := binding.JSON.BindBody(patched, &input); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	item, err := ic.service.UpdateItem(user, current.ID, current.Version, input.Name)
+	if err != nil {
+		respondItemError(c, err)
+		return
+	}
+	c.Header("ETag", utils.FormatETag(item.Version))
+	c.JSON(http.StatusOK, item)
+}
+
+func (ic *ItemController) DeleteItem(c *gin.Context) {
+	user, ok := middlewares.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+		return
+	}
+
+	current, ok := ic.currentItemForWrite(c, user)
+	if !ok {
+		return
+	}
+
+	if err := ic.service.DeleteItem(user, current.ID, current.Version); err != nil {
+		respondItemError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// currentItemForWrite loads the item addressed by the request and checks it against the
+// required If-Match header, responding with 428 when the header is missing and 412 when
+// the item has changed since the client read it.
+func (ic *ItemController) currentItemForWrite(c *gin.Context, user *models.User) (*models.Item, bool) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return nil, false
+	}
+
+	item, err := ic.service.GetItemByID(user, c.Param("id"))
+	if err != nil {
+		respondItemError(c, err)
+		return nil, false
+	}
+
+	if !utils.MatchETag(ifMatch, utils.FormatETag(item.Version), false) {
+		respondItemError(c, services.ErrVersionMismatch)
+		return nil, false
+	}
+	return item, true
+}
+
+// respondItemError hides items the user may not access behind the same 404 as missing ones.
+func respondItemError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrItemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Item not found"})
+		return
+	}
+	if errors.Is(err, services.ErrVersionMismatch) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPatch) || errors.Is(err, services.ErrInvalidSort) || errors.Is(err, utils.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
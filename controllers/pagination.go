@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"api-server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setPaginationLinks sets an RFC 8288 Link header with the first, prev, next and last pages
+// of the current request. Offset based pages get all relations, cursor based pages only "next".
+func setPaginationLinks(c *gin.Context, pagination models.Pagination) {
+	var links []string
+	addLink := func(rel string, set map[string]string) {
+		query := c.Request.URL.Query()
+		query.Del("cursor")
+		query.Del("offset")
+		for key, value := range set {
+			query.Set(key, value)
+		}
+		link := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, link.String(), rel))
+	}
+
+	limit := strconv.Itoa(pagination.Limit)
+
+	if pagination.Offset != nil {
+		offset := *pagination.Offset
+		addLink("first", map[string]string{"limit": limit})
+		if offset > 0 {
+			prev := offset - pagination.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			addLink("prev", map[string]string{"limit": limit, "offset": strconv.Itoa(prev)})
+		}
+		if pagination.HasMore {
+			addLink("next", map[string]string{"limit": limit, "offset": strconv.Itoa(offset + pagination.Limit)})
+		}
+		if pagination.Total != nil && *pagination.Total > 0 {
+			last := (*pagination.Total - 1) / int64(pagination.Limit) * int64(pagination.Limit)
+			addLink("last", map[string]string{"limit": limit, "offset": strconv.FormatInt(last, 10)})
+		}
+	} else if pagination.HasMore {
+		addLink("next", map[string]string{"limit": limit, "cursor": pagination.NextCursor})
+	}
+
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when an applied migration file was modified after it ran.
+var ErrChecksumMismatch = errors.New("applied migrations were modified")
+
+// migrationChecksum returns the hex encoded SHA-256 of the contents of an up migration file.
+func migrationChecksum(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+// migratorIdentity describes who applies a migration as "user@host".
+func migratorIdentity() string {
+	name := "unknown"
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return name + "@" + host
+}
+
+// readChecksum computes the checksum of the up file of an applied migration.
+func readChecksum(migrationsFS fs.FS, migrationID string) (string, error) {
+	upFile := migrationID + upSuffix
+	upSQL, err := fs.ReadFile(migrationsFS, upFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading migration file %s: %w", upFile, err)
+	}
+	return migrationChecksum(upSQL), nil
+}
+
+// verifyChecksums compares the recorded checksum of every applied migration with its file.
+// With backfill, migrations recorded before checksums were tracked get the current checksum.
+// Go migrations have no file and are not checked.
+func verifyChecksums(conn *sql.Conn, migrationsFS fs.FS, appliedMigrations map[string]appliedMigration, backfill bool) error {
+	var mismatched []string
+	for _, applied := range appliedMigrations {
+		if isGoMigration(applied.ID) {
+			continue
+		}
+		checksum, err := readChecksum(migrationsFS, applied.ID)
+		if err != nil {
+			return err
+		}
+
+		if !applied.Checksum.Valid {
+			if !backfill {
+				continue
+			}
+			if _, err := conn.ExecContext(context.Background(), `UPDATE schema_migrations SET checksum = $1 WHERE id = $2`, checksum, applied.ID); err != nil {
+				return fmt.Errorf("error recording checksum of migration %s: %w", applied.ID, err)
+			}
+			continue
+		}
+		if applied.Checksum.String != checksum {
+			mismatched = append(mismatched, applied.ID)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		return fmt.Errorf("%w after being applied: %s (restore the original files, or run with -repair if the change is intended)",
+			ErrChecksumMismatch, strings.Join(mismatched, ", "))
+	}
+	return nil
+}
+
+// RepairChecksums overwrites the recorded checksum of every applied migration with the checksum of its
+// current file. It is the escape hatch for intended edits to applied migrations, such as comment fixes.
+func RepairChecksums(db *sql.DB, migrationsFS fs.FS, opts Options) error {
+	// Wait for any other migrator to finish
+	conn, release, err := acquireMigrationLock(db, opts.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := ensureSchemaMigrationsTable(conn); err != nil {
+		return fmt.Errorf("error ensuring schema_migrations table: %w", err)
+	}
+
+	appliedMigrations, err := getAppliedMigrations(conn)
+	if err != nil {
+		return fmt.Errorf("error fetching applied migrations: %w", err)
+	}
+
+	for _, applied := range appliedMigrations {
+		if isGoMigration(applied.ID) {
+			continue
+		}
+		checksum, err := readChecksum(migrationsFS, applied.ID)
+		if err != nil {
+			return err
+		}
+		if applied.Checksum.Valid && applied.Checksum.String == checksum {
+			continue
+		}
+
+		log.Printf("Repairing checksum of migration: %s", applied.ID)
+		if _, err := conn.ExecContext(context.Background(), `UPDATE schema_migrations SET checksum = $1 WHERE id = $2`, checksum, applied.ID); err != nil {
+			return fmt.Errorf("error repairing checksum of migration %s: %w", applied.ID, err)
+		}
+	}
+
+	log.Println("Migration checksums repaired successfully")
+	return nil
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampVersionLayout formats migration versions in timestamp mode, e.g. 20240131154500.
+const timestampVersionLayout = "20060102150405"
+
+var nonNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateMigration writes an empty pair of up and down files for a new migration into the on-disk
+// migrations directory, which cannot be an embedded file system. The version is
+// the next sequence number after the highest existing version, zero-padded to six digits, or, with
+// timestamp, the current UTC time. It refuses to reuse a version that already exists.
+func CreateMigration(migrationsDir, name string, timestamp bool) (Migration, error) {
+	name = strings.Trim(nonNameChars.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return Migration{}, fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(migrationsDir), Options{})
+	if err != nil {
+		return Migration{}, err
+	}
+
+	var version uint64
+	var id string
+	if timestamp {
+		stamp := time.Now().UTC().Format(timestampVersionLayout)
+		version, _ = strconv.ParseUint(stamp, 10, 64)
+		id = fmt.Sprintf("%s_%s", stamp, name)
+	} else {
+		if len(migrations) > 0 {
+			version = migrations[len(migrations)-1].Version + 1
+		} else {
+			version = 1
+		}
+		id = fmt.Sprintf("%06d_%s", version, name)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return Migration{}, fmt.Errorf("migration version %d already exists: %s", version, migration.ID)
+		}
+	}
+
+	migration := Migration{
+		Version:  version,
+		Name:     name,
+		ID:       id,
+		UpFile:   id + upSuffix,
+		DownFile: id + downSuffix,
+	}
+	if err := writeNewFile(filepath.Join(migrationsDir, migration.UpFile)); err != nil {
+		return Migration{}, err
+	}
+	if err := writeNewFile(filepath.Join(migrationsDir, migration.DownFile)); err != nil {
+		os.Remove(filepath.Join(migrationsDir, migration.UpFile))
+		return Migration{}, err
+	}
+	return migration, nil
+}
+
+// writeNewFile creates an empty file, failing if it already exists.
+func writeNewFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating migration file: %w", err)
+	}
+	return file.Close()
+}
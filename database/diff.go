@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+)
+
+// SchemaDifference is a difference between the schema the migrations produce and the live schema.
+type SchemaDifference struct {
+	// Object names what differs, e.g. "table items" or "column items.name".
+	Object string
+	// Problem is "missing" when the live schema lacks the object, "unexpected" when no migration
+	// creates it and "changed" when both have it with different definitions.
+	Problem  string
+	Expected string
+	Actual   string
+}
+
+// DiffSchema compares the live schema with the schema the migrations describe. It builds the
+// expected schema by applying every migration to a temporary scratch schema in the same database,
+// which it drops afterwards, and reports tables, columns, indexes and constraints that differ,
+// e.g. because of hotfixes applied by hand. It holds the migration lock while running.
+func DiffSchema(db *sql.DB, migrationsFS fs.FS, opts Options) ([]SchemaDifference, error) {
+	migrations, err := LoadMigrations(migrationsFS, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for any other migrator, so the live schema does not change while comparing
+	conn, release, err := acquireMigrationLock(db, opts.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx := context.Background()
+	var liveSchema string
+	if err := conn.QueryRowContext(ctx, `SELECT current_schema()`).Scan(&liveSchema); err != nil {
+		return nil, err
+	}
+
+	scratchSchema, cleanup, err := createScratchSchema(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	// Build the expected schema; unqualified names resolve to the scratch schema only
+	log.Printf("Applying %d migrations to scratch schema %s", len(migrations), scratchSchema)
+	s := &session{conn: conn, migrationsFS: migrationsFS, migrations: migrations, quiet: true}
+	if err := s.loadApplied(); err != nil {
+		return nil, err
+	}
+	for _, migration := range migrations {
+		if err := s.apply(migration); err != nil {
+			return nil, err
+		}
+	}
+
+	expected, err := InspectSchema(ctx, conn, scratchSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting scratch schema: %w", err)
+	}
+	actual, err := InspectSchema(ctx, conn, liveSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting schema %s: %w", liveSchema, err)
+	}
+	return DiffSchemas(expected, actual), nil
+}
+
+// createScratchSchema creates an empty schema and points the search path of conn at it.
+// The returned cleanup drops the schema and restores the search path.
+func createScratchSchema(conn *sql.Conn) (string, func(), error) {
+	ctx := context.Background()
+	name := fmt.Sprintf("migrate_scratch_%d", os.Getpid())
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s; SET search_path TO %[1]s`, name)); err != nil {
+		return "", nil, fmt.Errorf("error creating scratch schema: %w", err)
+	}
+
+	cleanup := func() {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf(`RESET search_path; DROP SCHEMA IF EXISTS %s CASCADE`, name)); err != nil {
+			log.Printf("Error dropping scratch schema %s: %v", name, err)
+		}
+	}
+	return name, cleanup, nil
+}
+
+// DiffSchemas lists the differences between an expected and an actual schema, table by table,
+// followed by the sequences.
+func DiffSchemas(expected, actual *Schema) []SchemaDifference {
+	var differences []SchemaDifference
+	add := func(object, problem, expectedDefinition, actualDefinition string) {
+		differences = append(differences, SchemaDifference{Object: object, Problem: problem, Expected: expectedDefinition, Actual: actualDefinition})
+	}
+
+	actualTables := make(map[string]Table, len(actual.Tables))
+	for _, table := range actual.Tables {
+		actualTables[table.Name] = table
+	}
+
+	for _, want := range expected.Tables {
+		have, ok := actualTables[want.Name]
+		if !ok {
+			add("table "+want.Name, "missing", "", "")
+			continue
+		}
+		delete(actualTables, want.Name)
+
+		diffDefinitions(columnDefinitions(want), columnDefinitions(have), "column "+want.Name+".", add)
+		diffDefinitions(indexDefinitions(want), indexDefinitions(have), "index ", add)
+		diffDefinitions(constraintDefinitions(want), constraintDefinitions(have), "constraint "+want.Name+".", add)
+	}
+
+	for _, have := range actual.Tables {
+		if _, unexpected := actualTables[have.Name]; unexpected {
+			add("table "+have.Name, "unexpected", "", "")
+		}
+	}
+
+	diffDefinitions(sequenceDefinitions(expected), sequenceDefinitions(actual), "sequence ", add)
+	return differences
+}
+
+// definition is a named schema object with its definition, kept in schema order.
+type definition struct {
+	name       string
+	definition string
+}
+
+// diffDefinitions reports the objects missing from, unexpected in or changed in have compared to want.
+func diffDefinitions(want, have []definition, prefix string, add func(object, problem, expected, actual string)) {
+	haveByName := make(map[string]string, len(have))
+	for _, d := range have {
+		haveByName[d.name] = d.definition
+	}
+	wantByName := make(map[string]bool, len(want))
+
+	for _, d := range want {
+		wantByName[d.name] = true
+		actual, ok := haveByName[d.name]
+		switch {
+		case !ok:
+			add(prefix+d.name, "missing", d.definition, "")
+		case actual != d.definition:
+			add(prefix+d.name, "changed", d.definition, actual)
+		}
+	}
+	for _, d := range have {
+		if !wantByName[d.name] {
+			add(prefix+d.name, "unexpected", "", d.definition)
+		}
+	}
+}
+
+func columnDefinitions(table Table) []definition {
+	definitions := make([]definition, 0, len(table.Columns))
+	for _, column := range table.Columns {
+		definitions = append(definitions, definition{column.Name, column.String()})
+	}
+	return definitions
+}
+
+func indexDefinitions(table Table) []definition {
+	definitions := make([]definition, 0, len(table.Indexes))
+	for _, index := range table.Indexes {
+		definitions = append(definitions, definition{index.Name, index.Definition})
+	}
+	return definitions
+}
+
+func sequenceDefinitions(schema *Schema) []definition {
+	definitions := make([]definition, 0, len(schema.Sequences))
+	for _, sequence := range schema.Sequences {
+		d := sequence.String()
+		if sequence.OwnedBy != "" {
+			d += " OWNED BY " + sequence.OwnedBy
+		}
+		definitions = append(definitions, definition{sequence.Name, d})
+	}
+	return definitions
+}
+
+func constraintDefinitions(table Table) []definition {
+	definitions := make([]definition, 0, len(table.Constraints))
+	for _, constraint := range table.Constraints {
+		definitions = append(definitions, definition{constraint.Name, constraint.Definition})
+	}
+	return definitions
+}
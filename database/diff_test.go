@@ -0,0 +1,99 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	items := Table{
+		Name: "items",
+		Columns: []Column{
+			{Name: "id", Type: "integer", Default: "nextval('items_id_seq'::regclass)"},
+			{Name: "name", Type: "character varying(255)"},
+		},
+		Indexes: []Index{
+			{Name: "items_pkey", Definition: "CREATE UNIQUE INDEX items_pkey ON items USING btree (id)", Constraint: true},
+		},
+		Constraints: []Constraint{
+			{Name: "items_pkey", Definition: "PRIMARY KEY (id)"},
+		},
+	}
+	itemsSequence := Sequence{Name: "items_id_seq", Type: "integer", Start: 1, Increment: 1, Min: 1, Max: 2147483647, Cache: 1, OwnedBy: "items.id"}
+	expected := &Schema{Tables: []Table{items}, Sequences: []Sequence{itemsSequence}}
+
+	tests := []struct {
+		name   string
+		actual func() *Schema
+		want   []SchemaDifference
+	}{
+		{
+			name:   "identical",
+			actual: func() *Schema { return expected },
+			want:   nil,
+		},
+		{
+			name:   "missing table",
+			actual: func() *Schema { return &Schema{Sequences: []Sequence{itemsSequence}} },
+			want:   []SchemaDifference{{Object: "table items", Problem: "missing"}},
+		},
+		{
+			name: "unexpected table",
+			actual: func() *Schema {
+				return &Schema{Tables: []Table{items, {Name: "hotfix"}}, Sequences: []Sequence{itemsSequence}}
+			},
+			want: []SchemaDifference{{Object: "table hotfix", Problem: "unexpected"}},
+		},
+		{
+			name: "missing, unexpected and changed columns",
+			actual: func() *Schema {
+				table := items
+				table.Columns = []Column{
+					{Name: "id", Type: "bigint", Default: "nextval('items_id_seq'::regclass)"},
+					{Name: "notes", Type: "text", Nullable: true},
+				}
+				return &Schema{Tables: []Table{table}, Sequences: []Sequence{itemsSequence}}
+			},
+			want: []SchemaDifference{
+				{Object: "column items.id", Problem: "changed", Expected: "integer NOT NULL DEFAULT nextval('items_id_seq'::regclass)", Actual: "bigint NOT NULL DEFAULT nextval('items_id_seq'::regclass)"},
+				{Object: "column items.name", Problem: "missing", Expected: "character varying(255) NOT NULL"},
+				{Object: "column items.notes", Problem: "unexpected", Actual: "text"},
+			},
+		},
+		{
+			name: "unexpected index and missing constraint",
+			actual: func() *Schema {
+				table := items
+				table.Indexes = append(table.Indexes[:1:1], Index{Name: "idx_items_name", Definition: "CREATE INDEX idx_items_name ON items USING btree (name)"})
+				table.Constraints = nil
+				return &Schema{Tables: []Table{table}, Sequences: []Sequence{itemsSequence}}
+			},
+			want: []SchemaDifference{
+				{Object: "index idx_items_name", Problem: "unexpected", Actual: "CREATE INDEX idx_items_name ON items USING btree (name)"},
+				{Object: "constraint items.items_pkey", Problem: "missing", Expected: "PRIMARY KEY (id)"},
+			},
+		},
+		{
+			name: "changed sequence",
+			actual: func() *Schema {
+				sequence := itemsSequence
+				sequence.Increment = 10
+				return &Schema{Tables: []Table{items}, Sequences: []Sequence{sequence}}
+			},
+			want: []SchemaDifference{{
+				Object:   "sequence items_id_seq",
+				Problem:  "changed",
+				Expected: "CREATE SEQUENCE items_id_seq AS integer START WITH 1 INCREMENT BY 1 MINVALUE 1 MAXVALUE 2147483647 CACHE 1 OWNED BY items.id",
+				Actual:   "CREATE SEQUENCE items_id_seq AS integer START WITH 1 INCREMENT BY 10 MINVALUE 1 MAXVALUE 2147483647 CACHE 1 OWNED BY items.id",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffSchemas(expected, tt.actual()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSchemas() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readAppliedMigrations reads schema_migrations without creating or altering it. A missing table
+// means nothing is applied; of a table created by an earlier version of the migrator, only the
+// bookkeeping columns it already has are read, so checksums are still verified without baselined.
+func readAppliedMigrations(conn *sql.Conn) (map[string]appliedMigration, error) {
+	ctx := context.Background()
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	err = scanRows(rows, func() error {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return err
+		}
+		existing[column] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !existing["id"] {
+		return map[string]appliedMigration{}, nil
+	}
+
+	var applied appliedMigration
+	var columns []string
+	var dest []interface{}
+	for _, field := range []struct {
+		column string
+		dest   interface{}
+	}{
+		{"id", &applied.ID},
+		{"checksum", &applied.Checksum},
+		{"applied_at", &applied.AppliedAt},
+		{"execution_ms", &applied.ExecutionMs},
+		{"applied_by", &applied.AppliedBy},
+		{"baselined", &applied.Baselined},
+	} {
+		if existing[field.column] {
+			columns = append(columns, field.column)
+			dest = append(dest, field.dest)
+		}
+	}
+
+	rows, err = conn.QueryContext(ctx, `SELECT `+strings.Join(columns, ", ")+` FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	appliedMigrations := make(map[string]appliedMigration)
+	err = scanRows(rows, func() error {
+		applied = appliedMigration{}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		appliedMigrations[applied.ID] = applied
+		return nil
+	})
+	return appliedMigrations, err
+}
+
+// scriptWriter renders migrations as a SQL script instead of executing them. It implements
+// execer, so the bookkeeping statements of recordFunc are rendered with their arguments inlined.
+type scriptWriter struct {
+	w io.Writer
+}
+
+func newScriptWriter(w io.Writer) *scriptWriter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &scriptWriter{w: w}
+}
+
+// writeHeader writes the statements preparing schema_migrations, which every run executes first.
+func (sw *scriptWriter) writeHeader() error {
+	_, err := fmt.Fprintf(sw.w, "-- Generated by the migrator on %s\n\n%s\n", time.Now().UTC().Format(time.RFC3339), schemaMigrationsTableSQL)
+	return err
+}
+
+// writeMigration writes the SQL of a migration and its bookkeeping, wrapped in a transaction
+// unless the migration opts out with noTransactionDirective.
+func (sw *scriptWriter) writeMigration(migrationID, direction, migrationSQL string, record recordFunc) error {
+	inTransaction := !hasNoTransactionDirective(migrationSQL)
+
+	migrationSQL = strings.TrimSpace(migrationSQL)
+	if !strings.HasSuffix(migrationSQL, ";") {
+		// On its own line, in case the file ends with a comment
+		migrationSQL += "\n;"
+	}
+
+	fmt.Fprintf(sw.w, "\n-- %s (%s)\n", migrationID, direction)
+	if inTransaction {
+		fmt.Fprintln(sw.w, "BEGIN;")
+	}
+	fmt.Fprintln(sw.w, migrationSQL)
+	if err := record(context.Background(), sw, 0); err != nil {
+		return err
+	}
+	if inTransaction {
+		_, err := fmt.Fprintln(sw.w, "COMMIT;")
+		return err
+	}
+	return nil
+}
+
+// writeBaseline writes the bookkeeping of a migration marked as applied without running it.
+func (sw *scriptWriter) writeBaseline(migrationID string, record recordFunc) error {
+	if _, err := fmt.Fprintf(sw.w, "\n-- %s (baseline)\n", migrationID); err != nil {
+		return err
+	}
+	return record(context.Background(), sw, 0)
+}
+
+// writeGoMigration notes where a Go migration would run. Its bookkeeping is left out, so running
+// the script does not mark the migration as applied without its code having run.
+func (sw *scriptWriter) writeGoMigration(migrationID, direction string) error {
+	_, err := fmt.Fprintf(sw.w, "\n-- %s (%s)\n-- Go migration: cannot be expressed as SQL, run it with cmd/migrate.\n", migrationID, direction)
+	return err
+}
+
+// placeholderPattern matches the $n placeholders of a statement.
+var placeholderPattern = regexp.MustCompile(`\$\d+`)
+
+// ExecContext writes the statement with its $n placeholders replaced by SQL literals.
+func (sw *scriptWriter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	// Replace all placeholders in one pass, so placeholders within the inlined values stay as they are
+	query = placeholderPattern.ReplaceAllStringFunc(query, func(placeholder string) string {
+		n, err := strconv.Atoi(placeholder[1:])
+		if err != nil || n < 1 || n > len(args) {
+			return placeholder
+		}
+		return sqlLiteral(args[n-1])
+	})
+	if _, err := fmt.Fprintf(sw.w, "%s;\n", query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+// sqlLiteral formats a statement argument as a SQL literal.
+func sqlLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case int, int64, uint64:
+		return fmt.Sprintf("%d", v)
+	default:
+		return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+	}
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestScriptWriterExecContext(t *testing.T) {
+	tests := []struct {
+		query string
+		args  []interface{}
+		want  string
+	}{
+		{
+			query: `INSERT INTO schema_migrations (id, checksum, execution_ms) VALUES ($1, $2, $3)`,
+			args:  []interface{}{"000001_create_items_table", nil, int64(12)},
+			want:  `INSERT INTO schema_migrations (id, checksum, execution_ms) VALUES ('000001_create_items_table', NULL, 12);`,
+		},
+		{
+			query: `SELECT $1, $10`,
+			args:  []interface{}{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"},
+			want:  `SELECT 'a', 'j';`,
+		},
+		{
+			query: `SELECT $1, $2`,
+			args:  []interface{}{"costs $2", "it's"},
+			want:  `SELECT 'costs $2', 'it''s';`,
+		},
+		{
+			query: `SELECT $1, $2`,
+			args:  []interface{}{"only one"},
+			want:  `SELECT 'only one', $2;`,
+		},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		if _, err := newScriptWriter(&b).ExecContext(context.Background(), tt.query, tt.args...); err != nil {
+			t.Fatalf("ExecContext(%q) returned error: %v", tt.query, err)
+		}
+		if got := strings.TrimSpace(b.String()); got != tt.want {
+			t.Errorf("ExecContext(%q, %v) wrote %q, want %q", tt.query, tt.args, got, tt.want)
+		}
+	}
+}
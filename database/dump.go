@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// plainIdentifier matches identifiers that can be written without quotes.
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// referenceTables are the tables whose rows are inserted by migrations rather than by the
+// application, with the columns to dump, in the order they have to be loaded. Columns filled by
+// defaults, such as created_at, are left out so the dump does not change between databases.
+var referenceTables = []struct {
+	name    string
+	columns []string
+}{
+	{"roles", []string{"id", "name", "description"}},
+	{"permissions", []string{"id", "name", "description"}},
+	{"role_permissions", []string{"role_id", "permission_id"}},
+}
+
+// DumpSchema writes the current schema of the database as a canonical SQL script: sequences,
+// tables with their columns, sequence ownership, constraints (foreign keys last) and indexes,
+// each group in name order, followed by the reference data inserted by migrations, such as roles
+// and permissions, and the applied migrations recorded as baselined. The output only changes
+// when the schema or reference data does, so it can be committed and reviewed, and loading it
+// into an empty database yields one that later migrations apply to as usual.
+func DumpSchema(db *sql.DB, w io.Writer) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var schemaName string
+	if err := conn.QueryRowContext(ctx, `SELECT current_schema()`).Scan(&schemaName); err != nil {
+		return err
+	}
+	schema, err := InspectSchema(ctx, conn, schemaName)
+	if err != nil {
+		return fmt.Errorf("error inspecting schema %s: %w", schemaName, err)
+	}
+
+	lastMigration := "none"
+	appliedMigrations, err := readAppliedMigrations(conn)
+	if err != nil {
+		return fmt.Errorf("error fetching applied migrations: %w", err)
+	}
+	var lastVersion uint64
+	for id := range appliedMigrations {
+		if version, _, err := parseMigrationID(id); err == nil && version >= lastVersion {
+			lastVersion, lastMigration = version, id
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Canonical schema generated by cmd/migrate -dump-schema. Do not edit by hand;\n")
+	fmt.Fprintf(&b, "-- change the schema through migrations instead.\n")
+	fmt.Fprintf(&b, "-- Last applied migration: %s\n", lastMigration)
+
+	if len(schema.Sequences) > 0 {
+		b.WriteString("\n")
+		for _, sequence := range schema.Sequences {
+			sequence.Name = quoteIdentifier(sequence.Name)
+			fmt.Fprintf(&b, "%s;\n", sequence)
+		}
+	}
+
+	for _, table := range schema.Tables {
+		fmt.Fprintf(&b, "\nCREATE TABLE %s (\n", quoteIdentifier(table.Name))
+		for i, column := range table.Columns {
+			separator := ","
+			if i == len(table.Columns)-1 {
+				separator = ""
+			}
+			fmt.Fprintf(&b, "    %s %s%s\n", quoteIdentifier(column.Name), column, separator)
+		}
+		b.WriteString(");\n")
+	}
+
+	var ownership []string
+	for _, sequence := range schema.Sequences {
+		if sequence.OwnedBy != "" {
+			table, column, _ := strings.Cut(sequence.OwnedBy, ".")
+			ownership = append(ownership, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s;\n", quoteIdentifier(sequence.Name), quoteIdentifier(table), quoteIdentifier(column)))
+		}
+	}
+	writeSection(&b, ownership)
+
+	// Foreign keys last, so every referenced key exists when they are created
+	var constraints, foreignKeys, indexes []string
+	for _, table := range schema.Tables {
+		for _, constraint := range table.Constraints {
+			statement := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;\n", quoteIdentifier(table.Name), quoteIdentifier(constraint.Name), constraint.Definition)
+			if strings.HasPrefix(constraint.Definition, "FOREIGN KEY") {
+				foreignKeys = append(foreignKeys, statement)
+			} else {
+				constraints = append(constraints, statement)
+			}
+		}
+		for _, index := range table.Indexes {
+			// Indexes of primary keys and unique constraints are created by the constraints
+			if !index.Constraint {
+				indexes = append(indexes, index.Definition+";\n")
+			}
+		}
+	}
+	writeSection(&b, constraints)
+	writeSection(&b, foreignKeys)
+	writeSection(&b, indexes)
+
+	if err := writeReferenceData(ctx, conn, &b, schema); err != nil {
+		return fmt.Errorf("error dumping reference data: %w", err)
+	}
+
+	// The applied migrations, so the migrator does not run them again on a database loaded from the dump
+	ids := make([]string, 0, len(appliedMigrations))
+	for id := range appliedMigrations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	fmt.Fprintf(&b, "\n%s\n", schemaMigrationsTableSQL)
+	for _, id := range ids {
+		var checksum interface{}
+		if applied := appliedMigrations[id]; applied.Checksum.Valid {
+			checksum = applied.Checksum.String
+		}
+		fmt.Fprintf(&b, "INSERT INTO schema_migrations (id, checksum, applied_at, applied_by, baselined) VALUES (%s, %s, NOW(), 'schema dump', TRUE);\n",
+			sqlLiteral(id), sqlLiteral(checksum))
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// writeReferenceData writes the rows of the reference tables that exist in the schema, and moves
+// their sequences past the dumped IDs.
+func writeReferenceData(ctx context.Context, conn *sql.Conn, b *strings.Builder, schema *Schema) error {
+	tables := make(map[string]Table, len(schema.Tables))
+	for _, table := range schema.Tables {
+		tables[table.Name] = table
+	}
+
+	for _, reference := range referenceTables {
+		table, ok := tables[reference.name]
+		if !ok {
+			continue
+		}
+		columnTypes := make(map[string]string, len(table.Columns))
+		for _, column := range table.Columns {
+			columnTypes[column.Name] = column.Type
+		}
+
+		var columns, selected []string
+		for _, column := range reference.columns {
+			if _, ok := columnTypes[column]; !ok {
+				return fmt.Errorf("table %s has no column %s", table.Name, column)
+			}
+			columns = append(columns, quoteIdentifier(column))
+			selected = append(selected, quoteIdentifier(column)+"::text")
+		}
+
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf(`SELECT %s FROM %s ORDER BY %s`,
+			strings.Join(selected, ", "), quoteIdentifier(table.Name), strings.Join(columns, ", ")))
+		if err != nil {
+			return err
+		}
+		var statements []string
+		err = scanRows(rows, func() error {
+			values := make([]sql.NullString, len(columns))
+			dest := make([]interface{}, len(values))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+
+			literals := make([]string, len(values))
+			for i, value := range values {
+				switch {
+				case !value.Valid:
+					literals[i] = "NULL"
+				case isUnquotedType(columnTypes[reference.columns[i]]):
+					literals[i] = value.String
+				default:
+					literals[i] = sqlLiteral(value.String)
+				}
+			}
+			statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);\n",
+				quoteIdentifier(table.Name), strings.Join(columns, ", "), strings.Join(literals, ", ")))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		writeSection(b, statements)
+	}
+
+	var sequences []string
+	for _, sequence := range schema.Sequences {
+		table, column, _ := strings.Cut(sequence.OwnedBy, ".")
+		for _, reference := range referenceTables {
+			if reference.name == table {
+				sequences = append(sequences, fmt.Sprintf("SELECT setval(%s, (SELECT MAX(%s) FROM %s));\n",
+					sqlLiteral(quoteIdentifier(sequence.Name)), quoteIdentifier(column), quoteIdentifier(table)))
+			}
+		}
+	}
+	writeSection(b, sequences)
+	return nil
+}
+
+// isUnquotedType reports whether values of a column type are written as they are, without quotes.
+func isUnquotedType(columnType string) bool {
+	switch columnType {
+	case "smallint", "integer", "bigint", "boolean":
+		return true
+	}
+	return false
+}
+
+// writeSection writes a group of statements preceded by a blank line.
+func writeSection(b *strings.Builder, statements []string) {
+	if len(statements) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	for _, statement := range statements {
+		b.WriteString(statement)
+	}
+}
+
+// quoteIdentifier quotes an identifier unless it can be written as it is.
+func quoteIdentifier(name string) string {
+	if plainIdentifier.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
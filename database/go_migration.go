@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GoMigrationFunc changes the database from Go, for data changes that plain SQL cannot express.
+// It runs inside the transaction that also records the migration in schema_migrations.
+type GoMigrationFunc func(tx *sql.Tx) error
+
+var (
+	goMigrationsMu sync.Mutex
+	goMigrations   = make(map[uint64]Migration)
+)
+
+// RegisterGoMigration registers a migration implemented in Go. It runs in version order together
+// with the .sql migrations and is tracked in the same schema_migrations table under the ID
+// "<version>_<name>". It is meant to be called from init functions and panics if the version is
+// already registered or a function is missing.
+func RegisterGoMigration(version uint64, name string, up, down GoMigrationFunc) {
+	goMigrationsMu.Lock()
+	defer goMigrationsMu.Unlock()
+
+	if up == nil || down == nil {
+		panic(fmt.Sprintf("database: Go migration %d_%s needs both an up and a down function", version, name))
+	}
+	if existing, ok := goMigrations[version]; ok {
+		panic(fmt.Sprintf("database: Go migration version %d registered twice (%s and %d_%s)", version, existing.ID, version, name))
+	}
+
+	goMigrations[version] = Migration{
+		Version: version,
+		Name:    name,
+		ID:      fmt.Sprintf("%06d_%s", version, name),
+		Up:      up,
+		Down:    down,
+	}
+}
+
+// registeredGoMigrations returns a copy of the registered Go migrations keyed by version.
+func registeredGoMigrations() map[uint64]Migration {
+	goMigrationsMu.Lock()
+	defer goMigrationsMu.Unlock()
+
+	registered := make(map[uint64]Migration, len(goMigrations))
+	for version, migration := range goMigrations {
+		registered[version] = migration
+	}
+	return registered
+}
+
+// isGoMigration reports whether migrationID belongs to a registered Go migration.
+func isGoMigration(migrationID string) bool {
+	for _, migration := range registeredGoMigrations() {
+		if migration.ID == migrationID {
+			return true
+		}
+	}
+	return false
+}
+
+// execGoMigration runs a Go migration together with the statement recording it, in a single transaction.
+func execGoMigration(conn *sql.Conn, migrate GoMigrationFunc, record recordFunc) error {
+	ctx := context.Background()
+	started := time.Now()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := migrate(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := record(ctx, tx, time.Since(started)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migrationLockKey identifies the PostgreSQL advisory lock held while migrating.
+// Every migrator of the same database contends for this key.
+const migrationLockKey int64 = 0x6d69677261746531 // "migrate1"
+
+// lockRetryInterval is how often a waiting migrator retries to take the lock.
+const lockRetryInterval = 500 * time.Millisecond
+
+// ErrMigrationLocked is returned when the migration lock could not be taken within the lock timeout.
+var ErrMigrationLocked = errors.New("another process is running migrations")
+
+// acquireMigrationLock reserves a connection and takes the migration advisory lock on it,
+// retrying until timeout elapses. Advisory locks belong to a session, so all migration work
+// must run on the returned connection; release unlocks it and returns it to the pool.
+func acquireMigrationLock(db *sql.DB, timeout time.Duration) (*sql.Conn, func(), error) {
+	ctx := context.Background()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reserving connection for migration lock: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockKey).Scan(&locked); err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("error taking migration lock: %w", err)
+		}
+		if locked {
+			break
+		}
+
+		holder := DescribeLockHolder(conn, migrationLockKey)
+		if time.Now().After(deadline) {
+			conn.Close()
+			return nil, nil, fmt.Errorf("%w (held by %s), gave up after %s", ErrMigrationLocked, holder, timeout)
+		}
+		if !waiting {
+			log.Printf("Waiting up to %s for migration lock held by %s", timeout, holder)
+			waiting = true
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	release := func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			log.Printf("Error releasing migration lock: %v", err)
+		}
+		conn.Close()
+	}
+	return conn, release, nil
+}
+
+// DescribeLockHolder returns a description of the session holding the advisory lock with the given
+// key, for diagnostics. The scheduler uses it for the locks of its jobs as well.
+func DescribeLockHolder(conn *sql.Conn, key int64) string {
+	var pid int
+	var application, client string
+	var since time.Time
+
+	// A bigint advisory key is split into classid (high 32 bits) and objid (low 32 bits)
+	err := conn.QueryRowContext(context.Background(), `
+		SELECT a.pid, COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), 'local'), a.backend_start
+		FROM pg_locks l
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+		AND l.classid = $1 AND l.objid = $2
+		LIMIT 1`,
+		uint32(uint64(key)>>32), uint32(uint64(key)),
+	).Scan(&pid, &application, &client, &since)
+	if err != nil {
+		return "an unknown session"
+	}
+
+	return fmt.Sprintf("pid %d (application %q, client %s, connected since %s)", pid, application, client, since.Format(time.RFC3339))
+}
@@ -1,165 +1,572 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
-	"os"
-	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
-// ensureSchemaMigrationsTable ensures the schema_migrations table exists.
-func ensureSchemaMigrationsTable(db *sql.DB) error {
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS schema_migrations (
-			id TEXT PRIMARY KEY
-		);
-	`)
+// Options configures how migrations are run.
+type Options struct {
+	// LockTimeout is how long to wait for another process holding the migration lock.
+	// With zero, migrating fails immediately when the lock is held.
+	LockTimeout time.Duration
+	// RequireContiguous rejects gaps between migration versions, e.g. 000003 following 000001.
+	RequireContiguous bool
+	// DryRun writes the SQL that would run, including the schema_migrations bookkeeping, to Output
+	// instead of executing it. The database is only read to find the applied migrations.
+	DryRun bool
+	// Output receives the dry run SQL script; it defaults to standard output.
+	Output io.Writer
+}
+
+// noTransactionDirective opts a migration file out of running inside a transaction when it
+// appears on a line of its own, e.g. for CREATE INDEX CONCURRENTLY.
+const noTransactionDirective = "-- migrate:no-transaction"
+
+// appliedMigration is a row of the schema_migrations table.
+// Rows recorded before checksums were tracked have no checksum, timestamp, duration or applier.
+// Baselined migrations were marked as applied without running and have no duration.
+type appliedMigration struct {
+	ID          string
+	Checksum    sql.NullString
+	AppliedAt   sql.NullTime
+	ExecutionMs sql.NullInt64
+	AppliedBy   sql.NullString
+	Baselined   bool
+}
+
+// execer is implemented by connections and transactions alike.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// recordFunc updates schema_migrations after the SQL of a migration ran for elapsed.
+type recordFunc func(ctx context.Context, exec execer, elapsed time.Duration) error
+
+// schemaMigrationsTableSQL creates the schema_migrations table, or adds the bookkeeping
+// columns to one created by an older version of the migrator.
+const schemaMigrationsTableSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	id TEXT PRIMARY KEY
+);
+ALTER TABLE schema_migrations
+	ADD COLUMN IF NOT EXISTS checksum TEXT,
+	ADD COLUMN IF NOT EXISTS applied_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS execution_ms BIGINT,
+	ADD COLUMN IF NOT EXISTS applied_by TEXT,
+	ADD COLUMN IF NOT EXISTS baselined BOOLEAN NOT NULL DEFAULT FALSE;`
+
+// ensureSchemaMigrationsTable ensures the schema_migrations table exists with all of its bookkeeping columns.
+func ensureSchemaMigrationsTable(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), schemaMigrationsTableSQL)
 	return err
 }
 
-// getAppliedMigrations returns the list of migrations already applied in the database.
-func getAppliedMigrations(db *sql.DB) (map[string]bool, error) {
-	appliedMigrations := make(map[string]bool)
-	rows, err := db.Query(`SELECT id FROM schema_migrations`)
+// getAppliedMigrations returns the migrations already applied in the database, keyed by ID.
+func getAppliedMigrations(conn *sql.Conn) (map[string]appliedMigration, error) {
+	appliedMigrations := make(map[string]appliedMigration)
+	rows, err := conn.QueryContext(context.Background(), `SELECT id, checksum, applied_at, execution_ms, applied_by, baselined FROM schema_migrations`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
+		var applied appliedMigration
+		if err := rows.Scan(&applied.ID, &applied.Checksum, &applied.AppliedAt, &applied.ExecutionMs, &applied.AppliedBy, &applied.Baselined); err != nil {
 			return nil, err
 		}
-		appliedMigrations[id] = true
+		appliedMigrations[applied.ID] = applied
 	}
 
-	return appliedMigrations, nil
+	return appliedMigrations, rows.Err()
 }
 
-// getMigrationFiles reads all the migration files from the directory.
-func getMigrationFiles(migrationsDir string) ([]string, error) {
-	var migrations []string
+// recordApplied inserts the schema_migrations row of a newly applied migration.
+// Go migrations have no file to checksum and pass an empty checksum, which is stored as NULL.
+func recordApplied(migrationID, checksum string) recordFunc {
+	return func(ctx context.Context, exec execer, elapsed time.Duration) error {
+		var checksumValue interface{}
+		if checksum != "" {
+			checksumValue = checksum
+		}
+		_, err := exec.ExecContext(ctx,
+			`INSERT INTO schema_migrations (id, checksum, applied_at, execution_ms, applied_by) VALUES ($1, $2, NOW(), $3, $4)`,
+			migrationID, checksumValue, elapsed.Milliseconds(), migratorIdentity(),
+		)
+		return err
+	}
+}
 
-	err := filepath.WalkDir(migrationsDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+// recordBaselined inserts the schema_migrations row of a migration marked as applied without running it.
+func recordBaselined(migrationID, checksum string) recordFunc {
+	return func(ctx context.Context, exec execer, elapsed time.Duration) error {
+		var checksumValue interface{}
+		if checksum != "" {
+			checksumValue = checksum
 		}
-		if strings.HasSuffix(d.Name(), ".up.sql") {
-			migrationID := strings.TrimSuffix(d.Name(), ".up.sql")
-			migrations = append(migrations, migrationID)
+		_, err := exec.ExecContext(ctx,
+			`INSERT INTO schema_migrations (id, checksum, applied_at, applied_by, baselined) VALUES ($1, $2, NOW(), $3, TRUE)`,
+			migrationID, checksumValue, migratorIdentity(),
+		)
+		return err
+	}
+}
+
+// recordRolledBack deletes the schema_migrations row of a rolled back migration.
+func recordRolledBack(migrationID string) recordFunc {
+	return func(ctx context.Context, exec execer, elapsed time.Duration) error {
+		_, err := exec.ExecContext(ctx, `DELETE FROM schema_migrations WHERE id = $1`, migrationID)
+		return err
+	}
+}
+
+// hasNoTransactionDirective reports whether the migration SQL contains noTransactionDirective.
+func hasNoTransactionDirective(migrationSQL string) bool {
+	for _, line := range strings.Split(migrationSQL, "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), noTransactionDirective) {
+			return true
 		}
-		return nil
-	})
+	}
+	return false
+}
 
+// execMigration runs the SQL of a migration together with the statement recording it in schema_migrations.
+// Both run in a single transaction, so a failing migration leaves neither the schema nor the bookkeeping changed.
+// Files marked with noTransactionDirective run statement by statement in autocommit mode instead, and are
+// only recorded once every statement succeeded.
+func execMigration(conn *sql.Conn, migrationSQL string, record recordFunc) error {
+	ctx := context.Background()
+	started := time.Now()
+
+	if hasNoTransactionDirective(migrationSQL) {
+		for _, statement := range splitStatements(migrationSQL) {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return record(ctx, conn, time.Since(started))
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, migrationSQL); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := record(ctx, tx, time.Since(started)); err != nil {
+		tx.Rollback()
+		return err
 	}
+	return tx.Commit()
+}
 
-	return migrations, nil
+// session is a migration run that holds the migration lock, with the migration files
+// validated, the applied migrations loaded and their checksums verified. A dry run session
+// holds no lock and writes to script instead of executing.
+type session struct {
+	conn              *sql.Conn
+	migrationsFS      fs.FS
+	migrations        []Migration
+	appliedMigrations map[string]appliedMigration
+	script            *scriptWriter
+	quiet             bool
 }
 
-// ApplyMigrations applies any pending migrations and checks for missing migration files.
-func ApplyMigrations(db *sql.DB, migrationsDir string) error {
-	// Ensure the schema_migrations table exists
-	if err := ensureSchemaMigrationsTable(db); err != nil {
-		return fmt.Errorf("error ensuring schema_migrations table: %w", err)
+// openSession prepares a migration run. Closing the returned function releases the migration lock.
+func openSession(db *sql.DB, migrationsFS fs.FS, opts Options) (*session, func(), error) {
+	// Read and validate the migration files before touching the database
+	migrations, err := LoadMigrations(migrationsFS, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Get applied migrations from the database
-	appliedMigrations, err := getAppliedMigrations(db)
+	var conn *sql.Conn
+	var release func()
+	var script *scriptWriter
+	if opts.DryRun {
+		// Only read schema_migrations, so there is no need to wait for other migrators
+		if conn, err = db.Conn(context.Background()); err != nil {
+			return nil, nil, err
+		}
+		release = func() { conn.Close() }
+		script = newScriptWriter(opts.Output)
+	} else {
+		// Wait for any other migrator to finish
+		if conn, release, err = acquireMigrationLock(db, opts.LockTimeout); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	s := &session{conn: conn, migrationsFS: migrationsFS, migrations: migrations, script: script}
+	if err := s.loadApplied(); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return s, release, nil
+}
+
+func (s *session) loadApplied() error {
+	var appliedMigrations map[string]appliedMigration
+	var err error
+	if s.script != nil {
+		// Read without creating or altering schema_migrations; the script does that instead
+		appliedMigrations, err = readAppliedMigrations(s.conn)
+		if err == nil {
+			err = s.script.writeHeader()
+		}
+	} else {
+		// Ensure the schema_migrations table exists
+		if err := ensureSchemaMigrationsTable(s.conn); err != nil {
+			return fmt.Errorf("error ensuring schema_migrations table: %w", err)
+		}
+
+		// Get applied migrations from the database
+		appliedMigrations, err = getAppliedMigrations(s.conn)
+	}
 	if err != nil {
 		return fmt.Errorf("error fetching applied migrations: %w", err)
 	}
 
-	// Get migration files from the directory
-	migrationFiles, err := getMigrationFiles(migrationsDir)
+	// Check if any applied migration is missing from the directory
+	if err := checkMissingMigrations(s.migrations, appliedMigrations); err != nil {
+		return err
+	}
+
+	// Refuse to run if an applied migration was edited afterwards
+	if err := verifyChecksums(s.conn, s.migrationsFS, appliedMigrations, s.script == nil); err != nil {
+		return err
+	}
+
+	s.appliedMigrations = appliedMigrations
+	return nil
+}
+
+func (s *session) isApplied(migration Migration) bool {
+	_, applied := s.appliedMigrations[migration.ID]
+	return applied
+}
+
+func (s *session) hasVersion(version uint64) bool {
+	for _, migration := range s.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// applied returns the applied migrations, last applied first.
+func (s *session) applied() []Migration {
+	var applied []Migration
+	for i := len(s.migrations) - 1; i >= 0; i-- {
+		if s.isApplied(s.migrations[i]) {
+			applied = append(applied, s.migrations[i])
+		}
+	}
+	return applied
+}
+
+// apply runs the up file of a migration and records it.
+func (s *session) apply(migration Migration) error {
+	if migration.IsGo() {
+		s.logf("Applying Go migration: %s", migration.ID)
+		if err := s.execGo(migration, "up", migration.Up, recordApplied(migration.ID, "")); err != nil {
+			return fmt.Errorf("error applying migration %s: %w", migration.ID, err)
+		}
+		s.appliedMigrations[migration.ID] = appliedMigration{ID: migration.ID}
+		return nil
+	}
+
+	upSQL, err := fs.ReadFile(s.migrationsFS, migration.UpFile)
 	if err != nil {
-		return fmt.Errorf("error reading migration files: %w", err)
+		return fmt.Errorf("error reading migration file %s: %w", migration.UpFile, err)
 	}
 
-	// Check if any applied migration is missing from the directory
-	for appliedMigration := range appliedMigrations {
-		found := false
-		for _, migration := range migrationFiles {
-			if appliedMigration == migration {
-				found = true
-				break
-			}
+	s.logf("Applying migration: %s", migration.ID)
+	if err := s.exec(migration, "up", string(upSQL), recordApplied(migration.ID, migrationChecksum(upSQL))); err != nil {
+		return fmt.Errorf("error applying migration %s: %w", migration.ID, err)
+	}
+	s.appliedMigrations[migration.ID] = appliedMigration{ID: migration.ID}
+	return nil
+}
+
+// rollback runs the down file of a migration and removes it from schema_migrations.
+func (s *session) rollback(migration Migration) error {
+	if migration.IsGo() {
+		s.logf("Rolling back Go migration: %s", migration.ID)
+		if err := s.execGo(migration, "down", migration.Down, recordRolledBack(migration.ID)); err != nil {
+			return fmt.Errorf("error rolling back migration %s: %w", migration.ID, err)
+		}
+		delete(s.appliedMigrations, migration.ID)
+		return nil
+	}
+
+	downSQL, err := fs.ReadFile(s.migrationsFS, migration.DownFile)
+	if err != nil {
+		return fmt.Errorf("error reading migration file %s: %w", migration.DownFile, err)
+	}
+
+	s.logf("Rolling back migration: %s", migration.ID)
+	if err := s.exec(migration, "down", string(downSQL), recordRolledBack(migration.ID)); err != nil {
+		return fmt.Errorf("error rolling back migration %s: %w", migration.ID, err)
+	}
+	delete(s.appliedMigrations, migration.ID)
+	return nil
+}
+
+// baseline records a migration as applied without running it, within tx, or in the script in a dry run.
+func (s *session) baseline(tx *sql.Tx, migration Migration) error {
+	var checksum string
+	if !migration.IsGo() {
+		upSQL, err := fs.ReadFile(s.migrationsFS, migration.UpFile)
+		if err != nil {
+			return fmt.Errorf("error reading migration file %s: %w", migration.UpFile, err)
 		}
-		if !found {
-			// If an applied migration is missing from the filesystem, throw an error and exit
-			return fmt.Errorf("critical error: migration %s is missing from the migrations directory", appliedMigration)
+		checksum = migrationChecksum(upSQL)
+	}
+
+	s.logf("Baselining migration: %s", migration.ID)
+	record := recordBaselined(migration.ID, checksum)
+	var err error
+	if s.script != nil {
+		err = s.script.writeBaseline(migration.ID, record)
+	} else {
+		err = record(context.Background(), tx, 0)
+	}
+	if err != nil {
+		return fmt.Errorf("error baselining migration %s: %w", migration.ID, err)
+	}
+	return nil
+}
+
+// exec runs the SQL of a migration with its bookkeeping, or writes both to the script in a dry run.
+func (s *session) exec(migration Migration, direction, migrationSQL string, record recordFunc) error {
+	if s.script != nil {
+		return s.script.writeMigration(migration.ID, direction, migrationSQL, record)
+	}
+	return execMigration(s.conn, migrationSQL, record)
+}
+
+// execGo runs a Go migration with its bookkeeping. A dry run cannot render Go code as SQL,
+// so it only writes a note where the migration would run.
+func (s *session) execGo(migration Migration, direction string, migrate GoMigrationFunc, record recordFunc) error {
+	if s.script != nil {
+		return s.script.writeGoMigration(migration.ID, direction)
+	}
+	return execGoMigration(s.conn, migrate, record)
+}
+
+// logf logs progress, marking messages of dry runs.
+func (s *session) logf(format string, args ...interface{}) {
+	if s.quiet {
+		return
+	}
+	if s.script != nil {
+		format = "[dry run] " + format
+	}
+	log.Printf(format, args...)
+}
+
+// checkMissingMigrations fails when an applied migration no longer has files in the migrations directory.
+func checkMissingMigrations(migrations []Migration, appliedMigrations map[string]appliedMigration) error {
+	known := make(map[string]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.ID] = true
+	}
+
+	var missing []string
+	for id := range appliedMigrations {
+		if !known[id] {
+			missing = append(missing, id)
 		}
 	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("critical error: applied migrations are missing from the migrations directory: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ApplyMigrations applies any pending migrations and checks for missing migration files.
+// It holds the migration lock while running, so concurrent migrators apply each migration only once.
+func ApplyMigrations(db *sql.DB, migrationsFS fs.FS, opts Options) error {
+	s, release, err := openSession(db, migrationsFS, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
 
 	// Apply pending migrations
-	for _, migrationID := range migrationFiles {
-		if !appliedMigrations[migrationID] {
-			upSQLPath := filepath.Join(migrationsDir, fmt.Sprintf("%s.up.sql", migrationID))
-
-			// Read the up migration SQL file
-			upSQL, err := os.ReadFile(upSQLPath)
-			if err != nil {
-				return fmt.Errorf("error reading migration file %s: %w", upSQLPath, err)
+	for _, migration := range s.migrations {
+		if !s.isApplied(migration) {
+			if err := s.apply(migration); err != nil {
+				return err
 			}
+		}
+	}
+
+	s.logf("All migrations applied successfully")
+	return nil
+}
+
+// RollbackLastMigration rolls back the last applied migration while holding the migration lock.
+func RollbackLastMigration(db *sql.DB, migrationsFS fs.FS, opts Options) error {
+	return RollbackMigrations(db, migrationsFS, 1, opts)
+}
+
+// RollbackMigrations rolls back the last n applied migrations, newest first.
+func RollbackMigrations(db *sql.DB, migrationsFS fs.FS, n int, opts Options) error {
+	if n < 1 {
+		return fmt.Errorf("number of migrations to roll back must be positive, got %d", n)
+	}
+
+	s, release, err := openSession(db, migrationsFS, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
 
-			// Apply the migration
-			log.Printf("Applying migration: %s", migrationID)
-			if _, err := db.Exec(string(upSQL)); err != nil {
-				return fmt.Errorf("error applying migration %s: %w", migrationID, err)
+	applied := s.applied()
+	if len(applied) == 0 {
+		s.logf("No migrations found to rollback")
+		return nil
+	}
+	if n > len(applied) {
+		s.logf("Only %d migrations are applied, rolling back all of them", len(applied))
+		n = len(applied)
+	}
+
+	for _, migration := range applied[:n] {
+		if err := s.rollback(migration); err != nil {
+			return err
+		}
+	}
+
+	s.logf("Rolled back %d migrations successfully", n)
+	return nil
+}
+
+// MigrateTo applies or rolls back migrations until exactly the migrations up to and including
+// version are applied. Version 0 rolls back every migration.
+func MigrateTo(db *sql.DB, migrationsFS fs.FS, version uint64, opts Options) error {
+	s, release, err := openSession(db, migrationsFS, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if version != 0 && !s.hasVersion(version) {
+		return fmt.Errorf("no migration with version %d", version)
+	}
+
+	// Roll back newer migrations first, newest first
+	for _, migration := range s.applied() {
+		if migration.Version > version {
+			if err := s.rollback(migration); err != nil {
+				return err
 			}
+		}
+	}
 
-			// Record the applied migration
-			if _, err := db.Exec(`INSERT INTO schema_migrations (id) VALUES ($1)`, migrationID); err != nil {
-				return fmt.Errorf("error recording migration %s: %w", migrationID, err)
+	// Then apply older pending migrations, oldest first
+	for _, migration := range s.migrations {
+		if migration.Version <= version && !s.isApplied(migration) {
+			if err := s.apply(migration); err != nil {
+				return err
 			}
 		}
 	}
 
-	log.Println("All migrations applied successfully")
+	s.logf("Migrated to version %d successfully", version)
 	return nil
 }
 
-// RollbackLastMigration rolls back the last applied migration.
-func RollbackLastMigration(db *sql.DB, migrationsDir string) error {
-	// Get the last applied migration
-	row := db.QueryRow(`SELECT id FROM schema_migrations ORDER BY id DESC LIMIT 1`)
-	var lastMigrationID string
-	if err := row.Scan(&lastMigrationID); err != nil {
-		if err == sql.ErrNoRows {
-			log.Println("No migrations found to rollback")
-			return nil
-		}
+// RedoLastMigration rolls back the last applied migration and applies it again.
+func RedoLastMigration(db *sql.DB, migrationsFS fs.FS, opts Options) error {
+	s, release, err := openSession(db, migrationsFS, opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	applied := s.applied()
+	if len(applied) == 0 {
+		s.logf("No migrations found to redo")
+		return nil
+	}
+
+	last := applied[0]
+	if err := s.rollback(last); err != nil {
+		return err
+	}
+	if err := s.apply(last); err != nil {
+		return err
+	}
+
+	s.logf("Migration %s redone successfully", last.ID)
+	return nil
+}
+
+// BaselineMigrations marks every migration up to and including version as applied without running
+// it, for databases whose schema already exists, e.g. one created before adopting the migrator.
+// They are recorded as baselined; later migrations then apply as usual.
+func BaselineMigrations(db *sql.DB, migrationsFS fs.FS, version uint64, opts Options) error {
+	s, release, err := openSession(db, migrationsFS, opts)
+	if err != nil {
 		return err
 	}
+	defer release()
 
-	// Check if the migration file still exists
-	downSQLPath := filepath.Join(migrationsDir, fmt.Sprintf("%s.down.sql", lastMigrationID))
-	if _, err := os.Stat(downSQLPath); os.IsNotExist(err) {
-		return fmt.Errorf("critical error: migration file %s is missing", downSQLPath)
+	if !s.hasVersion(version) {
+		return fmt.Errorf("no migration with version %d", version)
 	}
 
-	// Read the down migration SQL file
-	downSQL, err := os.ReadFile(downSQLPath)
+	// Record all migrations in one transaction, so a failure leaves the history as it was
+	ctx := context.Background()
+	var tx *sql.Tx
+	if s.script != nil {
+		_, err = fmt.Fprintln(s.script.w, "\nBEGIN;")
+	} else {
+		tx, err = s.conn.BeginTx(ctx, nil)
+	}
 	if err != nil {
-		return fmt.Errorf("error reading migration file %s: %w", downSQLPath, err)
+		return err
+	}
+	if tx != nil {
+		defer tx.Rollback()
 	}
 
-	// Rollback the migration
-	log.Printf("Rolling back migration: %s", lastMigrationID)
-	if _, err := db.Exec(string(downSQL)); err != nil {
-		return fmt.Errorf("error rolling back migration %s: %w", lastMigrationID, err)
+	var baselined []Migration
+	for _, migration := range s.migrations {
+		if migration.Version <= version && !s.isApplied(migration) {
+			if err := s.baseline(tx, migration); err != nil {
+				return err
+			}
+			baselined = append(baselined, migration)
+		}
 	}
 
-	// Remove the migration from the schema_migrations table
-	if _, err := db.Exec(`DELETE FROM schema_migrations WHERE id = $1`, lastMigrationID); err != nil {
-		return fmt.Errorf("error deleting migration record %s: %w", lastMigrationID, err)
+	if s.script != nil {
+		_, err = fmt.Fprintln(s.script.w, "\nCOMMIT;")
+	} else {
+		err = tx.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("error committing baseline: %w", err)
+	}
+	for _, migration := range baselined {
+		s.appliedMigrations[migration.ID] = appliedMigration{ID: migration.ID, Baselined: true}
 	}
 
-	log.Printf("Migration %s rolled back successfully", lastMigrationID)
+	s.logf("Baselined %d migrations up to version %d", len(baselined), version)
 	return nil
 }
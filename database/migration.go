@@ -0,0 +1,139 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// Migration is a versioned schema change made of a pair of up and down files named
+// "<version>_<name>.up.sql" and "<version>_<name>.down.sql", or of a pair of Go functions
+// registered with RegisterGoMigration.
+type Migration struct {
+	// Version is the numeric prefix of the file names; migrations run in ascending version order.
+	Version uint64
+	// Name is the descriptive part of the file names.
+	Name string
+	// ID identifies the migration in schema_migrations, e.g. "000001_create_items_table".
+	ID string
+	// UpFile and DownFile are the file names within the migrations directory.
+	UpFile   string
+	DownFile string
+	// Up and Down are set instead of the files for Go migrations.
+	Up   GoMigrationFunc
+	Down GoMigrationFunc
+}
+
+// IsGo reports whether the migration is implemented in Go rather than SQL files.
+func (m Migration) IsGo() bool {
+	return m.Up != nil
+}
+
+// parseMigrationID splits an ID such as "000001_create_items_table" into its version and name.
+func parseMigrationID(id string) (uint64, string, error) {
+	prefix, name, found := strings.Cut(id, "_")
+	if !found || name == "" {
+		return 0, "", fmt.Errorf("migration %q is not named <version>_<name>", id)
+	}
+	version, err := strconv.ParseUint(prefix, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("migration %q does not start with a numeric version", id)
+	}
+	return version, name, nil
+}
+
+// LoadMigrations reads the migrations at the root of migrationsFS and merges in the registered
+// Go migrations, sorted by version. Subdirectories and files other than .sql files are ignored.
+// All problems are reported together, before any migration runs: malformed file names, versions
+// used by more than one migration, up files without a down file and vice versa, and, with
+// Options.RequireContiguous, gaps between versions.
+func LoadMigrations(migrationsFS fs.FS, opts Options) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	var errs []error
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		var id string
+		up := strings.HasSuffix(fileName, upSuffix)
+		switch {
+		case up:
+			id = strings.TrimSuffix(fileName, upSuffix)
+		case strings.HasSuffix(fileName, downSuffix):
+			id = strings.TrimSuffix(fileName, downSuffix)
+		default:
+			errs = append(errs, fmt.Errorf("migration file %s does not end in %s or %s", fileName, upSuffix, downSuffix))
+			continue
+		}
+
+		version, name, err := parseMigrationID(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name, ID: id}
+			byVersion[version] = migration
+		} else if migration.ID != id {
+			errs = append(errs, fmt.Errorf("version %d is used by both %s and %s", version, migration.ID, id))
+			continue
+		}
+
+		if up {
+			migration.UpFile = fileName
+		} else {
+			migration.DownFile = fileName
+		}
+	}
+
+	for version, goMigration := range registeredGoMigrations() {
+		if existing, ok := byVersion[version]; ok {
+			errs = append(errs, fmt.Errorf("version %d is used by both %s and Go migration %s", version, existing.ID, goMigration.ID))
+			continue
+		}
+		byVersion[version] = &goMigration
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, migration := range migrations {
+		if migration.UpFile == "" && !migration.IsGo() {
+			errs = append(errs, fmt.Errorf("migration %s has no %s file", migration.ID, upSuffix))
+		}
+		if migration.DownFile == "" && !migration.IsGo() {
+			errs = append(errs, fmt.Errorf("migration %s has no %s file", migration.ID, downSuffix))
+		}
+		if opts.RequireContiguous && i > 0 && migration.Version != migrations[i-1].Version+1 {
+			errs = append(errs, fmt.Errorf("gap between migration versions %d and %d", migrations[i-1].Version, migration.Version))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid migrations: %w", errors.Join(errs...))
+	}
+	return migrations, nil
+}
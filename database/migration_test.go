@@ -0,0 +1,157 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// migrationFS returns a file system with an empty file for each name.
+func migrationFS(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys[name] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	}
+	return fsys
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := migrationFS(
+		"000002_add_b.up.sql", "000002_add_b.down.sql",
+		"000001_add_a.up.sql", "000001_add_a.down.sql",
+		"000010_add_c.up.sql", "000010_add_c.down.sql",
+		"README.md",
+		"archive/000003_old.up.sql",
+	)
+
+	migrations, err := LoadMigrations(fsys, Options{})
+	if err != nil {
+		t.Fatalf("LoadMigrations returned error: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "add_a", ID: "000001_add_a", UpFile: "000001_add_a.up.sql", DownFile: "000001_add_a.down.sql"},
+		{Version: 2, Name: "add_b", ID: "000002_add_b", UpFile: "000002_add_b.up.sql", DownFile: "000002_add_b.down.sql"},
+		{Version: 10, Name: "add_c", ID: "000010_add_c", UpFile: "000010_add_c.up.sql", DownFile: "000010_add_c.down.sql"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("LoadMigrations returned %d migrations, want %d: %+v", len(migrations), len(want), migrations)
+	}
+	for i := range want {
+		if got := migrations[i]; got.Version != want[i].Version || got.Name != want[i].Name || got.ID != want[i].ID ||
+			got.UpFile != want[i].UpFile || got.DownFile != want[i].DownFile || got.IsGo() {
+			t.Errorf("migration %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		opts  Options
+		want  []string
+	}{
+		{
+			name:  "malformed name",
+			files: []string{"items.up.sql", "items.down.sql"},
+			want:  []string{`migration "items" is not named <version>_<name>`},
+		},
+		{
+			name:  "non-numeric version",
+			files: []string{"v1_create_items.up.sql", "v1_create_items.down.sql"},
+			want:  []string{`migration "v1_create_items" does not start with a numeric version`},
+		},
+		{
+			name:  "unknown suffix",
+			files: []string{"000001_create_items.sql"},
+			want:  []string{"migration file 000001_create_items.sql does not end in .up.sql or .down.sql"},
+		},
+		{
+			name:  "duplicate version",
+			files: []string{"000001_a.up.sql", "000001_a.down.sql", "000001_b.up.sql", "000001_b.down.sql"},
+			want:  []string{"version 1 is used by both 000001_a and 000001_b"},
+		},
+		{
+			name:  "missing down file",
+			files: []string{"000001_a.up.sql"},
+			want:  []string{"migration 000001_a has no .down.sql file"},
+		},
+		{
+			name:  "missing up file",
+			files: []string{"000001_a.down.sql"},
+			want:  []string{"migration 000001_a has no .up.sql file"},
+		},
+		{
+			name:  "gap with RequireContiguous",
+			files: []string{"000001_a.up.sql", "000001_a.down.sql", "000003_c.up.sql", "000003_c.down.sql"},
+			opts:  Options{RequireContiguous: true},
+			want:  []string{"gap between migration versions 1 and 3"},
+		},
+		{
+			name: "all problems together",
+			files: []string{
+				"000001_a.up.sql",
+				"000002_b.down.sql",
+				"bad.up.sql",
+			},
+			want: []string{
+				"migration 000001_a has no .down.sql file",
+				"migration 000002_b has no .up.sql file",
+				`migration "bad" is not named <version>_<name>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(migrationFS(tt.files...), tt.opts)
+			if err == nil {
+				t.Fatal("LoadMigrations succeeded, want an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMigrationsGapAllowedByDefault(t *testing.T) {
+	fsys := migrationFS("000001_a.up.sql", "000001_a.down.sql", "000003_c.up.sql", "000003_c.down.sql")
+	if _, err := LoadMigrations(fsys, Options{}); err != nil {
+		t.Errorf("LoadMigrations returned error for a gap without RequireContiguous: %v", err)
+	}
+}
+
+func TestLoadMigrationsMergesGoMigrations(t *testing.T) {
+	noop := func(tx *sql.Tx) error { return nil }
+	registerTestGoMigration(t, 2, "backfill", noop, noop)
+
+	fsys := migrationFS("000001_a.up.sql", "000001_a.down.sql", "000003_c.up.sql", "000003_c.down.sql")
+	migrations, err := LoadMigrations(fsys, Options{RequireContiguous: true})
+	if err != nil {
+		t.Fatalf("LoadMigrations returned error: %v", err)
+	}
+	if len(migrations) != 3 || migrations[1].ID != "000002_backfill" || !migrations[1].IsGo() {
+		t.Errorf("LoadMigrations = %+v, want the Go migration 000002_backfill between the SQL migrations", migrations)
+	}
+
+	fsys = migrationFS("000002_b.up.sql", "000002_b.down.sql")
+	if _, err := LoadMigrations(fsys, Options{}); err == nil || !strings.Contains(err.Error(), "version 2 is used by both 000002_b and Go migration 000002_backfill") {
+		t.Errorf("LoadMigrations error = %v, want a version conflict with the Go migration", err)
+	}
+}
+
+// registerTestGoMigration registers a Go migration for the duration of the test.
+func registerTestGoMigration(t *testing.T, version uint64, name string, up, down GoMigrationFunc) {
+	t.Helper()
+	RegisterGoMigration(version, name, up, down)
+	t.Cleanup(func() {
+		goMigrationsMu.Lock()
+		defer goMigrationsMu.Unlock()
+		delete(goMigrations, version)
+	})
+}
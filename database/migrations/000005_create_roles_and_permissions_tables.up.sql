@@ -0,0 +1,42 @@
+
+CREATE TABLE IF NOT EXISTS roles (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(50) UNIQUE NOT NULL,
+  description TEXT,
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(100) UNIQUE NOT NULL,
+  description TEXT,
+  created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+INSERT INTO roles (name, description) VALUES
+  ('admin', 'Full access, including role management'),
+  ('editor', 'Can read and modify items'),
+  ('viewer', 'Can read items')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, description) VALUES
+  ('items:read', 'List and view items'),
+  ('items:write', 'Create, update and delete items'),
+  ('roles:read', 'List roles and the roles of users'),
+  ('roles:assign', 'Assign roles to and remove roles from users')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+JOIN permissions p ON
+  r.name = 'admin'
+  OR (r.name = 'editor' AND p.name IN ('items:read', 'items:write'))
+  OR (r.name = 'viewer' AND p.name = 'items:read')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+
+DROP INDEX IF EXISTS idx_items_owner_id;
+
+ALTER TABLE items DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,4 @@
+
+ALTER TABLE items ADD COLUMN IF NOT EXISTS owner_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_items_owner_id ON items (owner_id);
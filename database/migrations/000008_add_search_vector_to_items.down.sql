@@ -0,0 +1,4 @@
+
+DROP INDEX IF EXISTS idx_items_search_vector;
+
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,2 @@
+
+DELETE FROM permissions WHERE name IN ('items:restore', 'users:delete', 'users:restore');
@@ -0,0 +1,12 @@
+
+INSERT INTO permissions (name, description) VALUES
+  ('items:restore', 'Restore deleted items'),
+  ('users:delete', 'Delete users'),
+  ('users:restore', 'Restore deleted users')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name IN ('items:restore', 'users:delete', 'users:restore')
+ON CONFLICT DO NOTHING;
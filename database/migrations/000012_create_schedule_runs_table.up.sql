@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS schedule_runs (
+  job VARCHAR(100) NOT NULL,
+  scheduled_for TIMESTAMPTZ NOT NULL,
+  claimed_by TEXT NOT NULL,
+  claimed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  finished_at TIMESTAMPTZ,
+  PRIMARY KEY (job, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_runs_claimed_at ON schedule_runs (claimed_at);
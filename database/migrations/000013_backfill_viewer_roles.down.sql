@@ -0,0 +1 @@
+-- The backfilled viewer roles cannot be told apart from those given on registration, so they are kept
@@ -0,0 +1,5 @@
+-- Users registered before roles existed get the default role, so they keep access to the
+-- permission-checked routes
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r WHERE r.name = 'viewer'
+ON CONFLICT DO NOTHING;
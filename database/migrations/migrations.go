@@ -0,0 +1,16 @@
+// Package migrations embeds the SQL migration files, so binaries can migrate
+// the database without the source tree being present. It is also the home of
+// Go migrations, which register themselves from init functions under the next
+// unused version number:
+//
+//	func init() {
+//		database.RegisterGoMigration(14, "backfill_item_owners", backfillItemOwnersUp, backfillItemOwnersDown)
+//	}
+package migrations
+
+import "embed"
+
+// FS holds every .sql file of this directory.
+//
+//go:embed *.sql
+var FS embed.FS
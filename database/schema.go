@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is the structure of the tables and sequences of a PostgreSQL schema, as read from the
+// system catalogs. Definitions are stored without the schema name, so schemas can be compared with each other.
+type Schema struct {
+	Tables    []Table
+	Sequences []Sequence
+}
+
+// Table is a table with its columns in creation order and its indexes and constraints sorted by name.
+type Table struct {
+	Name        string
+	Columns     []Column
+	Indexes     []Index
+	Constraints []Constraint
+}
+
+// Column is a column of a table.
+type Column struct {
+	Name     string
+	Type     string
+	Nullable bool
+	// Default is the default or generation expression, empty if there is none.
+	Default string
+	// Generated is set for stored generated columns, whose Default is the generation expression.
+	Generated bool
+}
+
+// String formats the column as in a CREATE TABLE statement, e.g. "integer NOT NULL DEFAULT 0".
+func (c Column) String() string {
+	definition := c.Type
+	if !c.Nullable {
+		definition += " NOT NULL"
+	}
+	if c.Generated {
+		definition += " GENERATED ALWAYS AS (" + c.Default + ") STORED"
+	} else if c.Default != "" {
+		definition += " DEFAULT " + c.Default
+	}
+	return definition
+}
+
+// Index is an index of a table with its CREATE INDEX statement.
+type Index struct {
+	Name       string
+	Definition string
+	// Constraint is set for indexes created by a primary key, unique or exclusion constraint.
+	Constraint bool
+}
+
+// Constraint is a constraint of a table, such as a primary key, foreign key, unique or check constraint.
+type Constraint struct {
+	Name       string
+	Definition string
+}
+
+// Sequence is a sequence with its parameters.
+type Sequence struct {
+	Name      string
+	Type      string
+	Start     int64
+	Increment int64
+	Min       int64
+	Max       int64
+	Cache     int64
+	Cycle     bool
+	// OwnedBy is the "table.column" the sequence belongs to, e.g. for serial columns; empty if none.
+	OwnedBy string
+}
+
+// String formats the sequence as a CREATE SEQUENCE statement without the ownership.
+func (s Sequence) String() string {
+	definition := fmt.Sprintf("CREATE SEQUENCE %s AS %s START WITH %d INCREMENT BY %d MINVALUE %d MAXVALUE %d CACHE %d",
+		s.Name, s.Type, s.Start, s.Increment, s.Min, s.Max, s.Cache)
+	if s.Cycle {
+		definition += " CYCLE"
+	}
+	return definition
+}
+
+// txBeginner is implemented by databases and connections alike.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// bookkeepingTables are managed by the migrator and seeder themselves and are left out of schemas.
+var bookkeepingTables = map[string]bool{"schema_migrations": true, "schema_seeds": true}
+
+// InspectSchema reads the tables, columns, indexes, constraints and sequences of a schema.
+func InspectSchema(ctx context.Context, db txBeginner, schemaName string) (*Schema, error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// With only the inspected schema on the search path, the catalog functions print the names
+	// of its objects without the schema name, while objects elsewhere stay qualified
+	if _, err := tx.ExecContext(ctx, `SELECT set_config('search_path', quote_ident($1), true)`, schemaName); err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]*Table)
+	var order []string
+	table := func(name string) *Table {
+		if _, ok := tables[name]; !ok {
+			tables[name] = &Table{Name: name}
+			order = append(order, name)
+		}
+		return tables[name]
+	}
+
+	// Tables, ordered by name, with their columns in creation order
+	rows, err := tx.QueryContext(ctx, `
+		SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attgenerated = 's'
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p')
+		ORDER BY c.relname, a.attnum`,
+		schemaName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = scanRows(rows, func() error {
+		var tableName string
+		var column Column
+		if err := rows.Scan(&tableName, &column.Name, &column.Type, &column.Nullable, &column.Default, &column.Generated); err != nil {
+			return err
+		}
+		t := table(tableName)
+		t.Columns = append(t.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Indexes, including those backing primary keys and unique constraints
+	rows, err = tx.QueryContext(ctx, `
+		SELECT t.relname, i.relname, pg_get_indexdef(i.oid),
+			EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.oid AND con.contype IN ('p', 'u', 'x'))
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1
+		ORDER BY t.relname, i.relname`,
+		schemaName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = scanRows(rows, func() error {
+		var tableName string
+		var index Index
+		if err := rows.Scan(&tableName, &index.Name, &index.Definition, &index.Constraint); err != nil {
+			return err
+		}
+		t := table(tableName)
+		t.Indexes = append(t.Indexes, index)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Constraints
+	rows, err = tx.QueryContext(ctx, `
+		SELECT c.relname, con.conname, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND con.contype IN ('p', 'f', 'u', 'c', 'x')
+		ORDER BY c.relname, con.conname`,
+		schemaName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = scanRows(rows, func() error {
+		var tableName string
+		var constraint Constraint
+		if err := rows.Scan(&tableName, &constraint.Name, &constraint.Definition); err != nil {
+			return err
+		}
+		t := table(tableName)
+		t.Constraints = append(t.Constraints, constraint)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(order)
+	schema := &Schema{}
+	for _, name := range order {
+		if !bookkeepingTables[name] {
+			schema.Tables = append(schema.Tables, *tables[name])
+		}
+	}
+
+	// Sequences, with the column they belong to
+	rows, err = tx.QueryContext(ctx, `
+		SELECT s.sequencename, s.data_type::text, s.start_value, s.increment_by, s.min_value, s.max_value,
+			s.cache_size, s.cycle, COALESCE(t.relname || '.' || a.attname, '')
+		FROM pg_sequences s
+		JOIN pg_namespace n ON n.nspname = s.schemaname
+		JOIN pg_class c ON c.relnamespace = n.oid AND c.relname = s.sequencename
+		LEFT JOIN pg_depend d ON d.objid = c.oid AND d.classid = 'pg_class'::regclass AND d.refclassid = 'pg_class'::regclass AND d.deptype IN ('a', 'i')
+		LEFT JOIN pg_class t ON t.oid = d.refobjid
+		LEFT JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+		WHERE s.schemaname = $1
+		ORDER BY s.sequencename`,
+		schemaName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = scanRows(rows, func() error {
+		var sequence Sequence
+		if err := rows.Scan(&sequence.Name, &sequence.Type, &sequence.Start, &sequence.Increment, &sequence.Min,
+			&sequence.Max, &sequence.Cache, &sequence.Cycle, &sequence.OwnedBy); err != nil {
+			return err
+		}
+		if !bookkeepingTables[strings.Split(sequence.OwnedBy, ".")[0]] {
+			schema.Sequences = append(schema.Sequences, sequence)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// scanRows calls scan for every row and closes the rows.
+func scanRows(rows *sql.Rows, scan func() error) error {
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
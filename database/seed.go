@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"api-server/utils"
+)
+
+// SeedEnvironments are the environments seed data can be loaded for; each has a directory of seed files.
+var SeedEnvironments = []string{"dev", "test", "demo"}
+
+// Seed is a versioned file of seed data for one environment, named "<version>_<name>.sql" for
+// plain SQL or "<version>_<name>.json" for a fixture of users and items.
+type Seed struct {
+	Version uint64
+	Name    string
+	// ID identifies the seed in schema_seeds, e.g. "000001_users_and_items".
+	ID string
+	// File is the path of the seed file within the seeds file system, e.g. "dev/000001_users_and_items.json".
+	File string
+}
+
+// seedFixture is the format of .json seed files.
+type seedFixture struct {
+	Users []struct {
+		Username string   `json:"username"`
+		Email    string   `json:"email"`
+		Password string   `json:"password"`
+		Roles    []string `json:"roles"`
+	} `json:"users"`
+	Items []struct {
+		Name string `json:"name"`
+		// Owner is the username of the owning user; empty for items without an owner.
+		Owner string `json:"owner"`
+	} `json:"items"`
+}
+
+// LoadSeeds reads the seeds of an environment, sorted by version.
+func LoadSeeds(seedsFS fs.FS, env string) ([]Seed, error) {
+	if !isSeedEnvironment(env) {
+		return nil, fmt.Errorf("unknown seed environment %q, expected one of %s", env, strings.Join(SeedEnvironments, ", "))
+	}
+
+	entries, err := fs.ReadDir(seedsFS, env)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]Seed)
+	var errs []error
+	for _, entry := range entries {
+		fileName := entry.Name()
+		extension := path.Ext(fileName)
+		if entry.IsDir() || (extension != ".sql" && extension != ".json") {
+			continue
+		}
+
+		id := strings.TrimSuffix(fileName, extension)
+		version, name, err := parseMigrationID(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if existing, ok := byVersion[version]; ok {
+			errs = append(errs, fmt.Errorf("version %d is used by both %s and %s", version, existing.File, fileName))
+			continue
+		}
+		byVersion[version] = Seed{Version: version, Name: name, ID: id, File: path.Join(env, fileName)}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid %s seeds: %w", env, errors.Join(errs...))
+	}
+
+	seeds := make([]Seed, 0, len(byVersion))
+	for _, seed := range byVersion {
+		seeds = append(seeds, seed)
+	}
+	sort.Slice(seeds, func(i, j int) bool {
+		return seeds[i].Version < seeds[j].Version
+	})
+	return seeds, nil
+}
+
+// ApplySeeds loads the pending seeds of an environment. Each seed runs in a transaction together
+// with its row in schema_seeds, which is tracked separately from schema_migrations, so every seed
+// is loaded once per database. Fixtures also skip users and items that already exist, so seeding
+// a database that has some of the data is safe. It holds the migration lock while running.
+func ApplySeeds(db *sql.DB, seedsFS fs.FS, env string, opts Options) error {
+	seeds, err := LoadSeeds(seedsFS, env)
+	if err != nil {
+		return err
+	}
+
+	// Wait for any migrator or other seeder to finish
+	conn, release, err := acquireMigrationLock(db, opts.LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx := context.Background()
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_seeds (
+			env TEXT NOT NULL,
+			id TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (env, id)
+		)`,
+	); err != nil {
+		return fmt.Errorf("error ensuring schema_seeds table: %w", err)
+	}
+
+	appliedSeeds, err := getAppliedSeeds(conn, env)
+	if err != nil {
+		return fmt.Errorf("error fetching applied seeds: %w", err)
+	}
+
+	for _, seed := range seeds {
+		contents, err := fs.ReadFile(seedsFS, seed.File)
+		if err != nil {
+			return fmt.Errorf("error reading seed file %s: %w", seed.File, err)
+		}
+		checksum := migrationChecksum(contents)
+
+		if appliedChecksum, applied := appliedSeeds[seed.ID]; applied {
+			if appliedChecksum != checksum {
+				log.Printf("Seed %s was modified after being applied; add a new seed file to load more data", seed.File)
+			}
+			continue
+		}
+
+		log.Printf("Applying seed: %s", seed.File)
+		if err := applySeed(ctx, conn, env, seed, contents, checksum); err != nil {
+			return fmt.Errorf("error applying seed %s: %w", seed.File, err)
+		}
+	}
+
+	log.Printf("All %s seeds applied successfully", env)
+	return nil
+}
+
+func isSeedEnvironment(env string) bool {
+	for _, known := range SeedEnvironments {
+		if env == known {
+			return true
+		}
+	}
+	return false
+}
+
+// getAppliedSeeds returns the checksums of the seeds already applied for an environment, keyed by ID.
+func getAppliedSeeds(conn *sql.Conn, env string) (map[string]string, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT id, checksum FROM schema_seeds WHERE env = $1`, env)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedSeeds := make(map[string]string)
+	for rows.Next() {
+		var id, checksum string
+		if err := rows.Scan(&id, &checksum); err != nil {
+			return nil, err
+		}
+		appliedSeeds[id] = checksum
+	}
+	return appliedSeeds, rows.Err()
+}
+
+// applySeed loads a seed file and records it in schema_seeds in a single transaction.
+func applySeed(ctx context.Context, conn *sql.Conn, env string, seed Seed, contents []byte, checksum string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if path.Ext(seed.File) == ".json" {
+		err = applyFixture(ctx, tx, contents)
+	} else {
+		_, err = tx.ExecContext(ctx, string(contents))
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_seeds (env, id, checksum) VALUES ($1, $2, $3)`,
+		env, seed.ID, checksum,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyFixture inserts the users and items of a JSON fixture, skipping those that already exist.
+func applyFixture(ctx context.Context, tx *sql.Tx, contents []byte) error {
+	var fixture seedFixture
+	if err := json.Unmarshal(contents, &fixture); err != nil {
+		return fmt.Errorf("invalid fixture: %w", err)
+	}
+
+	// Items are only attached to users created here, mapped from username to ID
+	created := make(map[string]int64)
+	skipped := make(map[string]bool)
+
+	for _, user := range fixture.Users {
+		if user.Username == "" || user.Email == "" || user.Password == "" {
+			return fmt.Errorf("fixture users need a username, email and password")
+		}
+
+		passwordHash, err := utils.HashPassword(user.Password)
+		if err != nil {
+			return err
+		}
+
+		// Roles are only granted to users created here, never to an existing account that
+		// happens to have the same username or email
+		var userID int64
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING RETURNING id`,
+			user.Username, user.Email, passwordHash,
+		).Scan(&userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Skipping seed user %s, which already exists, without granting roles", user.Username)
+			skipped[user.Username] = true
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error seeding user %s: %w", user.Username, err)
+		}
+		created[user.Username] = userID
+
+		for _, role := range user.Roles {
+			result, err := tx.ExecContext(ctx,
+				`INSERT INTO user_roles (user_id, role_id) SELECT $1, id FROM roles WHERE name = $2`,
+				userID, role,
+			)
+			if err != nil {
+				return fmt.Errorf("error assigning role %s to user %s: %w", role, user.Username, err)
+			}
+			if affected, _ := result.RowsAffected(); affected == 0 {
+				return fmt.Errorf("user %s has unknown role %s", user.Username, role)
+			}
+		}
+	}
+
+	for _, item := range fixture.Items {
+		if item.Name == "" {
+			return fmt.Errorf("fixture items need a name")
+		}
+
+		var ownerID sql.NullInt64
+		if item.Owner != "" {
+			id, ok := created[item.Owner]
+			if !ok && skipped[item.Owner] {
+				log.Printf("Skipping seed item %s, whose owner %s already existed", item.Name, item.Owner)
+				continue
+			}
+			if !ok {
+				return fmt.Errorf("item %s has owner %s, which is not a user of the fixture", item.Name, item.Owner)
+			}
+			ownerID = sql.NullInt64{Int64: id, Valid: true}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO items (name, owner_id)
+			SELECT $1::VARCHAR, $2::INTEGER
+			WHERE NOT EXISTS (SELECT 1 FROM items WHERE name = $1 AND owner_id IS NOT DISTINCT FROM $2)`,
+			item.Name, ownerID,
+		); err != nil {
+			return fmt.Errorf("error seeding item %s: %w", item.Name, err)
+		}
+	}
+
+	return nil
+}
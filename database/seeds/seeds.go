@@ -0,0 +1,9 @@
+// Package seeds embeds the seed data files of every environment.
+package seeds
+
+import "embed"
+
+// FS holds one directory of seed files per environment.
+//
+//go:embed dev test demo
+var FS embed.FS
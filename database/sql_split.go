@@ -0,0 +1,106 @@
+package database
+
+import "strings"
+
+// splitStatements splits a SQL script into its individual statements. Semicolons inside
+// string literals, quoted identifiers, dollar-quoted bodies and comments do not end a statement.
+// Statements consisting only of whitespace and comments are dropped.
+func splitStatements(script string) []string {
+	var statements []string
+	start := 0
+	hasCode := false
+
+	for i := 0; i < len(script); i++ {
+		switch ch := script[i]; {
+		case ch == '-' && strings.HasPrefix(script[i:], "--"):
+			// Line comment: skip to the end of the line
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case ch == '/' && strings.HasPrefix(script[i:], "/*"):
+			// Block comment, which may be nested in PostgreSQL
+			depth := 1
+			i += 2
+			for ; i < len(script) && depth > 0; i++ {
+				if strings.HasPrefix(script[i:], "/*") {
+					depth++
+					i++
+				} else if strings.HasPrefix(script[i:], "*/") {
+					depth--
+					i++
+				}
+			}
+			i--
+		case ch == '\'' || ch == '"':
+			// String literal or quoted identifier; doubled quotes are escapes,
+			// and backslash escapes apply to escape strings such as E'...', but not to typed
+			// literals such as DATE'...'
+			escapes := ch == '\'' && i > 0 && (script[i-1] == 'E' || script[i-1] == 'e') &&
+				(i < 2 || !isIdentifierChar(script[i-2]))
+			hasCode = true
+			for i++; i < len(script); i++ {
+				if escapes && script[i] == '\\' {
+					i++
+					continue
+				}
+				if script[i] == ch {
+					if i+1 < len(script) && script[i+1] == ch {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case ch == '$':
+			// Dollar-quoted string such as $$...$$ or $body$...$body$, unless the dollar sign
+			// is part of an identifier such as a$b
+			hasCode = true
+			if i > 0 && isIdentifierChar(script[i-1]) {
+				continue
+			}
+			if tag, ok := dollarQuoteTag(script[i:]); ok {
+				if end := strings.Index(script[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag) - 1
+				} else {
+					i = len(script)
+				}
+			}
+		case ch == ';':
+			if hasCode {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			start = i + 1
+			hasCode = false
+		case ch != ' ' && ch != '\t' && ch != '\n' && ch != '\r':
+			hasCode = true
+		}
+	}
+
+	if hasCode {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+	return statements
+}
+
+// dollarQuoteTag returns the opening tag (e.g. "$$" or "$body$") if s starts with one.
+func dollarQuoteTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		ch := s[i]
+		if ch == '$' {
+			return s[:i+1], true
+		}
+		isLetter := ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		isDigit := ch >= '0' && ch <= '9'
+		if !isLetter && !(isDigit && i > 1) {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// isIdentifierChar reports whether ch can be part of an unquoted identifier.
+func isIdentifierChar(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 0x80 || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "empty",
+			script: "",
+			want:   nil,
+		},
+		{
+			name:   "single statement without semicolon",
+			script: "SELECT 1",
+			want:   []string{"SELECT 1"},
+		},
+		{
+			name:   "several statements",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:   "empty statements and comments only",
+			script: ";;\n-- nothing here;\n/* nor; here */;",
+			want:   nil,
+		},
+		{
+			name:   "semicolon in string literal",
+			script: "INSERT INTO a VALUES ('x;y');SELECT 2",
+			want:   []string{"INSERT INTO a VALUES ('x;y')", "SELECT 2"},
+		},
+		{
+			name:   "doubled quote in string literal",
+			script: "SELECT 'it''s; fine'; SELECT 2",
+			want:   []string{"SELECT 'it''s; fine'", "SELECT 2"},
+		},
+		{
+			name:   "backslash escape in escape string",
+			script: `SELECT E'a\';b'; SELECT 2`,
+			want:   []string{`SELECT E'a\';b'`, "SELECT 2"},
+		},
+		{
+			name:   "backslash in standard string",
+			script: `SELECT 'a\'; SELECT 2`,
+			want:   []string{`SELECT 'a\'`, "SELECT 2"},
+		},
+		{
+			name:   "backslash in typed literal ending in e",
+			script: `SELECT DATE'a\'; SELECT 2`,
+			want:   []string{`SELECT DATE'a\'`, "SELECT 2"},
+		},
+		{
+			name:   "semicolon in quoted identifier",
+			script: `CREATE TABLE "a;b" (id INT); SELECT 2`,
+			want:   []string{`CREATE TABLE "a;b" (id INT)`, "SELECT 2"},
+		},
+		{
+			name:   "semicolon in line comment",
+			script: "SELECT 1 -- first; statement\n; SELECT 2",
+			want:   []string{"SELECT 1 -- first; statement", "SELECT 2"},
+		},
+		{
+			name:   "nested block comment",
+			script: "SELECT /* outer /* inner; */ still; comment */ 1; SELECT 2",
+			want:   []string{"SELECT /* outer /* inner; */ still; comment */ 1", "SELECT 2"},
+		},
+		{
+			name:   "dollar-quoted function body",
+			script: "CREATE FUNCTION f() RETURNS INT AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql; SELECT 2",
+			want:   []string{"CREATE FUNCTION f() RETURNS INT AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql", "SELECT 2"},
+		},
+		{
+			name:   "tagged dollar quote containing another tag",
+			script: "DO $body$ BEGIN PERFORM $$;$$; END $body$; SELECT 2",
+			want:   []string{"DO $body$ BEGIN PERFORM $$;$$; END $body$", "SELECT 2"},
+		},
+		{
+			name:   "positional parameter is not a dollar quote",
+			script: "PREPARE p AS SELECT $1; SELECT 2",
+			want:   []string{"PREPARE p AS SELECT $1", "SELECT 2"},
+		},
+		{
+			name:   "dollar sign inside identifier is not a dollar quote",
+			script: "SELECT a$b$c FROM t; SELECT 2",
+			want:   []string{"SELECT a$b$c FROM t", "SELECT 2"},
+		},
+		{
+			name:   "unterminated dollar quote runs to the end",
+			script: "SELECT $$ never closed; SELECT 2",
+			want:   []string{"SELECT $$ never closed; SELECT 2"},
+		},
+		{
+			name:   "unterminated string runs to the end",
+			script: "SELECT 'never closed; SELECT 2",
+			want:   []string{"SELECT 'never closed; SELECT 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDollarQuoteTag(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+		ok   bool
+	}{
+		{"$$ body $$", "$$", true},
+		{"$body$ x $body$", "$body$", true},
+		{"$_tag1$", "$_tag1$", true},
+		{"$1", "", false},
+		{"$1$", "", false},
+		{"$a b$", "", false},
+		{"$", "", false},
+		{"$abc", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := dollarQuoteTag(tt.s)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("dollarQuoteTag(%q) = %q, %v, want %q, %v", tt.s, got, ok, tt.want, tt.ok)
+		}
+	}
+}
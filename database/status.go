@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// MigrationState describes whether a migration has been applied.
+type MigrationState string
+
+const (
+	// StateApplied is a migration recorded in schema_migrations whose files exist.
+	StateApplied MigrationState = "applied"
+	// StatePending is a migration whose files exist but which has not been applied yet.
+	StatePending MigrationState = "pending"
+	// StateMissing is a migration recorded in schema_migrations whose files no longer exist.
+	StateMissing MigrationState = "missing"
+)
+
+// MigrationStatus is the state of a single migration. The applied fields are zero for
+// pending migrations and for migrations applied before they were tracked.
+type MigrationStatus struct {
+	Version       uint64
+	ID            string
+	State         MigrationState
+	AppliedAt     *time.Time
+	AppliedBy     string
+	ExecutionTime time.Duration
+	// Baselined is set for migrations marked as applied without running.
+	Baselined bool
+}
+
+// GetMigrationStatus lists every known migration, from the migrations directory and from
+// schema_migrations, sorted by version. It only reads the database and does not wait for the migration lock.
+func GetMigrationStatus(db *sql.DB, migrationsFS fs.FS, opts Options) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(migrationsFS, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Read without creating or altering schema_migrations, which would wait behind a running
+	// migrator and fail for read-only users
+	appliedMigrations, err := readAppliedMigrations(conn)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching applied migrations: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, ID: migration.ID, State: StatePending}
+		if applied, ok := appliedMigrations[migration.ID]; ok {
+			status = appliedStatus(applied, StateApplied)
+			status.Version = migration.Version
+			delete(appliedMigrations, migration.ID)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// Whatever is left in schema_migrations has no files any more
+	for _, applied := range appliedMigrations {
+		status := appliedStatus(applied, StateMissing)
+		status.Version, _, _ = parseMigrationID(applied.ID)
+		statuses = append(statuses, status)
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		if statuses[i].Version != statuses[j].Version {
+			return statuses[i].Version < statuses[j].Version
+		}
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses, nil
+}
+
+func appliedStatus(applied appliedMigration, state MigrationState) MigrationStatus {
+	status := MigrationStatus{ID: applied.ID, State: state, AppliedBy: applied.AppliedBy.String, Baselined: applied.Baselined}
+	if applied.AppliedAt.Valid {
+		appliedAt := applied.AppliedAt.Time
+		status.AppliedAt = &appliedAt
+	}
+	if applied.ExecutionMs.Valid {
+		status.ExecutionTime = time.Duration(applied.ExecutionMs.Int64) * time.Millisecond
+	}
+	return status
+}
@@ -4,8 +4,11 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"api-server/config"
+	"api-server/database"
+	"api-server/database/migrations"
 	"api-server/middlewares"
 	"api-server/routes"
 
@@ -33,6 +36,12 @@ func main() {
 	}
 	gin.SetMode(ginMode)
 
+	// Load authentication settings
+	authConfig, err := config.LoadAuthConfig()
+	if err != nil {
+		log.Fatalf("Error loading auth configuration: %v", err)
+	}
+
 	// Initialize database
 	db, err := config.InitDB()
 	if err != nil {
@@ -40,6 +49,13 @@ func main() {
 	}
 	defer db.Close()
 
+	// Apply pending migrations from the embedded SQL files if enabled
+	if os.Getenv("AUTO_MIGRATE") == "true" {
+		if err := database.ApplyMigrations(db, migrations.FS, database.Options{LockTimeout: time.Minute}); err != nil {
+			log.Fatalf("Error applying migrations: %v", err)
+		}
+	}
+
 	// Router: Initialize router
 	router := gin.Default()
 
@@ -57,7 +73,7 @@ func main() {
 	router.Use(middlewares.ErrorHandler)
 
 	// Router: Setup routes
-	routes.SetupRoutes(router, db)
+	routes.SetupRoutes(router, db, authConfig)
 
 	// Get port from environment variables or use default
 	port := os.Getenv("APP_PORT")
@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"api-server/models"
+	"api-server/repositories"
+	"api-server/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set by AuthRequired.
+const (
+	ContextUserKey   = "user"
+	ContextClaimsKey = "claims"
+)
+
+// AuthRequired verifies the bearer access token and stores the authenticated user in the context.
+// The user's roles and permissions are loaded as well so that RequirePermission can check them.
+func AuthRequired(tokens *services.TokenService, users *repositories.UserRepository, roles *services.RoleService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			abortUnauthorized(c, "missing bearer token")
+			return
+		}
+
+		claims, err := tokens.ParseAccessToken(tokenString)
+		if err != nil {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+
+		// Reject tokens revoked by a logout
+		revoked, err := tokens.IsRevoked(claims)
+		if errors.Is(err, services.ErrInvalidToken) {
+			abortUnauthorized(c, err.Error())
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+			return
+		}
+		if revoked {
+			abortUnauthorized(c, "token has been revoked")
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			abortUnauthorized(c, services.ErrInvalidToken.Error())
+			return
+		}
+
+		// Load the user so that deleted accounts lose access immediately
+		user, err := users.GetUserByID(userID)
+		if err != nil {
+			abortUnauthorized(c, services.ErrInvalidToken.Error())
+			return
+		}
+
+		if err := roles.LoadAuthorization(user); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to load permissions"})
+			return
+		}
+
+		c.Set(ContextUserKey, user)
+		c.Set(ContextClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user stored in the context by AuthRequired.
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	value, exists := c.Get(ContextUserKey)
+	if !exists {
+		return nil, false
+	}
+	user, ok := value.(*models.User)
+	return user, ok
+}
+
+// CurrentClaims returns the access token claims stored in the context by AuthRequired.
+func CurrentClaims(c *gin.Context) (*services.AccessClaims, bool) {
+	value, exists := c.Get(ContextClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*services.AccessClaims)
+	return claims, ok
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header.
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+}
@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission aborts with 403 unless the authenticated user has the permission.
+// It must run after AuthRequired.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			abortUnauthorized(c, "not authenticated")
+			return
+		}
+
+		if !user.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":      "forbidden",
+				"permission": permission,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
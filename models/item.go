@@ -1,6 +1,15 @@
 package models
 
 type Item struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	OwnerID *int64 `json:"owner_id"`
+	Version int64  `json:"version"`
+}
+
+// ItemSearchResult is an item matched by a full-text search.
+type ItemSearchResult struct {
+	Item
+	Rank      float32 `json:"rank"`
+	Highlight string  `json:"highlight"`
 }
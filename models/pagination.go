@@ -0,0 +1,11 @@
+package models
+
+// Pagination describes the position of a page within a list result.
+// Offset and Total are only set for offset based pages; NextCursor is set whenever more results follow.
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Offset     *int   `json:"offset,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
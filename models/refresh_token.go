@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RefreshToken represents a stored (hashed) refresh token.
+// Tokens created by rotating one another share the same FamilyID.
+type RefreshToken struct {
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	FamilyID  string     `json:"family_id"`
+	TokenHash string     `json:"-"`
+	Device    string     `json:"device"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
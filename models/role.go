@@ -0,0 +1,19 @@
+package models
+
+// Built-in roles created by the migrations.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+
+	// DefaultRole is assigned to newly registered users.
+	DefaultRole = RoleViewer
+)
+
+// Role represents a named set of permissions.
+type Role struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
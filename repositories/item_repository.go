@@ -2,10 +2,16 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"api-server/models"
 )
 
+// AnyOwner can be passed as ownerID to skip the ownership filter.
+const AnyOwner int64 = 0
+
 type ItemRepository struct {
 	db *sql.DB
 }
@@ -14,38 +20,253 @@ func NewItemRepository(db *sql.DB) *ItemRepository {
 	return &ItemRepository{db: db}
 }
 
-func (r *ItemRepository) GetAll() ([]models.Item, error) {
-	rows, err := r.db.Query("SELECT id, name FROM items")
+// ItemFilter restricts which items are listed.
+type ItemFilter struct {
+	OwnerID      int64
+	Name         string
+	NamePrefix   string
+	NameContains string
+}
+
+// ItemCursor is the keyset position of the last item of a page.
+type ItemCursor struct {
+	Sort string `json:"s"`
+	Name string `json:"n,omitempty"`
+	ID   int64  `json:"i"`
+}
+
+// ItemListOptions controls filtering, ordering and paging of List.
+// SortField must be one of the whitelisted item sort fields; After takes precedence over Offset.
+type ItemListOptions struct {
+	Filter    ItemFilter
+	SortField string
+	SortDesc  bool
+	Limit     int
+	Offset    int
+	After     *ItemCursor
+}
+
+// ItemSortFields are the columns items can be ordered by.
+var ItemSortFields = map[string]bool{
+	"id":   true,
+	"name": true,
+}
+
+func (r *ItemRepository) List(opts ItemListOptions) ([]models.Item, error) {
+	if !ItemSortFields[opts.SortField] {
+		return nil, fmt.Errorf("unsupported sort field: %s", opts.SortField)
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	where, args := opts.Filter.where()
+
+	// Keyset pagination: continue strictly after the cursor position, using id as tiebreaker
+	if opts.After != nil {
+		if opts.SortField == "name" {
+			args = append(args, opts.After.Name, opts.After.ID)
+			where = append(where, fmt.Sprintf("(name, id) %s ($%d, $%d)", comparison, len(args)-1, len(args)))
+		} else {
+			args = append(args, opts.After.ID)
+			where = append(where, fmt.Sprintf("id %s $%d", comparison, len(args)))
+		}
+	}
+
+	query := "SELECT id, name, owner_id, version FROM items WHERE " + strings.Join(where, " AND ")
+	if opts.SortField == "id" {
+		query += fmt.Sprintf(" ORDER BY id %s", direction)
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", opts.SortField, direction, direction)
+	}
+
+	args = append(args, opts.Limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+	if opts.After == nil && opts.Offset > 0 {
+		args = append(args, opts.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var items []models.Item
+	items := []models.Item{}
 	for rows.Next() {
 		var item models.Item
-		if err := rows.Scan(&item.ID, &item.Name); err != nil {
+		if err := rows.Scan(&item.ID, &item.Name, &item.OwnerID, &item.Version); err != nil {
 			return nil, err
 		}
 		items = append(items, item)
 	}
-	return items, nil
+	return items, rows.Err()
+}
+
+// Count returns the number of items matching the filter.
+func (r *ItemRepository) Count(filter ItemFilter) (int64, error) {
+	where, args := filter.where()
+
+	var total int64
+	err := r.db.QueryRow("SELECT COUNT(*) FROM items WHERE "+strings.Join(where, " AND "), args...).Scan(&total)
+	return total, err
+}
+
+// ItemSearchCursor is the keyset position of the last result of a search page.
+type ItemSearchCursor struct {
+	Query string  `json:"q"`
+	Rank  float32 `json:"r"`
+	ID    int64   `json:"i"`
+}
+
+// ItemSearchOptions controls paging of Search; After takes precedence over Offset.
+type ItemSearchOptions struct {
+	Query   string
+	OwnerID int64
+	Limit   int
+	Offset  int
+	After   *ItemSearchCursor
+}
+
+// Search runs a full-text search over item names, ordered by relevance.
+// Query uses the web search syntax of websearch_to_tsquery (quoted phrases, "or", "-" to exclude).
+func (r *ItemRepository) Search(opts ItemSearchOptions) ([]models.ItemSearchResult, error) {
+	args := []interface{}{opts.Query, opts.OwnerID}
+	query := `SELECT id, name, owner_id, version, rank,
+			ts_headline('english', name, query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+		FROM (
+			SELECT id, name, owner_id, version, query, ts_rank(search_vector, query) AS rank
+			FROM items, websearch_to_tsquery('english', $1) AS query
+			WHERE search_vector @@ query AND deleted_at IS NULL AND ($2 = 0 OR owner_id = $2)
+		) ranked`
+
+	// Keyset pagination over (rank DESC, id ASC)
+	if opts.After != nil {
+		args = append(args, opts.After.Rank, opts.After.ID)
+		query += " WHERE rank < $3::real OR (rank = $3::real AND id > $4)"
+	}
+
+	args = append(args, opts.Limit)
+	query += fmt.Sprintf(" ORDER BY rank DESC, id ASC LIMIT $%d", len(args))
+	if opts.After == nil && opts.Offset > 0 {
+		args = append(args, opts.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.ItemSearchResult{}
+	for rows.Next() {
+		var result models.ItemSearchResult
+		if err := rows.Scan(&result.ID, &result.Name, &result.OwnerID, &result.Version, &result.Rank, &result.Highlight); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// CountSearch returns the number of items matching a full-text search.
+func (r *ItemRepository) CountSearch(query string, ownerID int64) (int64, error) {
+	var total int64
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM items WHERE search_vector @@ websearch_to_tsquery('english', $1) AND deleted_at IS NULL AND ($2 = 0 OR owner_id = $2)`, query, ownerID).
+		Scan(&total)
+	return total, err
+}
+
+// where returns the SQL conditions and arguments for the filter.
+func (f ItemFilter) where() ([]string, []interface{}) {
+	where := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+
+	if f.OwnerID != AnyOwner {
+		args = append(args, f.OwnerID)
+		where = append(where, fmt.Sprintf("owner_id = $%d", len(args)))
+	}
+	if f.Name != "" {
+		args = append(args, f.Name)
+		where = append(where, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if f.NamePrefix != "" {
+		args = append(args, escapeLike(f.NamePrefix)+"%")
+		where = append(where, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+	if f.NameContains != "" {
+		args = append(args, "%"+escapeLike(f.NameContains)+"%")
+		where = append(where, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+
+	return where, args
 }
 
-func (r *ItemRepository) GetByID(id string) (*models.Item, error) {
+// escapeLike escapes the LIKE wildcards in s so that it is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (r *ItemRepository) GetByID(id string, ownerID int64) (*models.Item, error) {
 	var item models.Item
-	err := r.db.QueryRow("SELECT id, name FROM items WHERE id = $1", id).Scan(&item.ID, &item.Name)
+	err := r.db.QueryRow("SELECT id, name, owner_id, version FROM items WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR owner_id = $2)", id, ownerID).
+		Scan(&item.ID, &item.Name, &item.OwnerID, &item.Version)
 	if err != nil {
 		return nil, err
 	}
 	return &item, nil
 }
 
-func (r *ItemRepository) Create(name string) (*models.Item, error) {
+func (r *ItemRepository) Create(name string, ownerID int64) (*models.Item, error) {
 	var item models.Item
-	err := r.db.QueryRow("INSERT INTO items (name) VALUES ($1) RETURNING id, name", name).Scan(&item.ID, &item.Name)
+	err := r.db.QueryRow("INSERT INTO items (name, owner_id) VALUES ($1, $2) RETURNING id, name, owner_id, version", name, ownerID).
+		Scan(&item.ID, &item.Name, &item.OwnerID, &item.Version)
 	if err != nil {
 		return nil, err
 	}
 	return &item, nil
 }
+
+// Update changes an item if it is still at the expected version, incrementing the version.
+func (r *ItemRepository) Update(id string, ownerID int64, version int64, name string) (*models.Item, error) {
+	var item models.Item
+	err := r.db.QueryRow("UPDATE items SET name = $4, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR owner_id = $2) AND version = $3 RETURNING id, name, owner_id, version", id, ownerID, version, name).
+		Scan(&item.ID, &item.Name, &item.OwnerID, &item.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Delete soft deletes an item if it is still at the expected version; it is hidden from every other query until restored or purged.
+func (r *ItemRepository) Delete(id string, ownerID int64, version int64) error {
+	result, err := r.db.Exec("UPDATE items SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR owner_id = $2) AND version = $3", id, ownerID, version)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// Restore undoes the soft delete of an item.
+func (r *ItemRepository) Restore(id string) (*models.Item, error) {
+	var item models.Item
+	err := r.db.QueryRow("UPDATE items SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id, name, owner_id, version", id).
+		Scan(&item.ID, &item.Name, &item.OwnerID, &item.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// PurgeDeleted permanently deletes items soft deleted before the cutoff and returns how many were removed.
+func (r *ItemRepository) PurgeDeleted(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM items WHERE deleted_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// recorder is a database/sql driver that records the statements executed through it and
+// reports a fixed number of affected rows, for testing repositories without a database.
+type recorder struct {
+	mu       sync.Mutex
+	queries  []string
+	args     [][]driver.NamedValue
+	affected int64
+}
+
+func (r *recorder) Open(string) (driver.Conn, error) {
+	return &recorderConn{r}, nil
+}
+
+type recorderConn struct {
+	r *recorder
+}
+
+func (c *recorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.r.queries = append(c.r.queries, query)
+	c.r.args = append(c.r.args, args)
+	return driver.RowsAffected(c.r.affected), nil
+}
+
+func (c *recorderConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("recorder: only Exec is supported")
+}
+
+func (c *recorderConn) Close() error {
+	return nil
+}
+
+func (c *recorderConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("recorder: transactions are not supported")
+}
+
+// newRecorderDB returns a database recording its statements, each reporting affected rows.
+func newRecorderDB(t *testing.T, affected int64) (*sql.DB, *recorder) {
+	t.Helper()
+	r := &recorder{affected: affected}
+	db := sql.OpenDB(connector{r})
+	t.Cleanup(func() { db.Close() })
+	return db, r
+}
+
+type connector struct {
+	r *recorder
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return c.r.Open("")
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.r
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"api-server/models"
+	"database/sql"
+)
+
+type RefreshTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create stores a new refresh token.
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (user_id, family_id, token_hash, device, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	err := r.db.QueryRow(query, token.UserID, token.FamilyID, token.TokenHash, token.Device, token.ExpiresAt).Scan(&token.ID, &token.CreatedAt)
+	return err
+}
+
+// GetByHash retrieves a refresh token by the hash of its value.
+func (r *RefreshTokenRepository) GetByHash(hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	var device sql.NullString
+	query := `SELECT id, user_id, family_id, token_hash, device, expires_at, used_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1`
+	err := r.db.QueryRow(query, hash).Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &device, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Device = device.String
+	return &token, nil
+}
+
+// MarkUsed marks an active token as used. It reports false if the token was already used or revoked,
+// which makes concurrent refreshes with the same token detectable.
+func (r *RefreshTokenRepository) MarkUsed(id int64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// RevokeFamily revokes every token that descends from the same login.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`, familyID)
+	return err
+}
+
+// RevokeAllForUser revokes every active refresh token of the user.
+func (r *RefreshTokenRepository) RevokeAllForUser(userID int64) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	return err
+}
+
+// PurgeExpired deletes refresh tokens that have expired, whether used, revoked or not, and
+// returns how many were removed. Expired tokens are rejected anyway, so reuse detection does not
+// need them.
+func (r *RefreshTokenRepository) PurgeExpired() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import "testing"
+
+func TestRefreshTokenRepositoryPurgeExpired(t *testing.T) {
+	db, recorded := newRecorderDB(t, 3)
+
+	purged, err := NewRefreshTokenRepository(db).PurgeExpired()
+	if err != nil {
+		t.Fatalf("PurgeExpired returned error: %v", err)
+	}
+	if purged != 3 {
+		t.Errorf("PurgeExpired() = %d, want 3", purged)
+	}
+
+	want := `DELETE FROM refresh_tokens WHERE expires_at < NOW()`
+	if len(recorded.queries) != 1 || recorded.queries[0] != want {
+		t.Errorf("PurgeExpired executed %q, want only %q", recorded.queries, want)
+	}
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import "database/sql"
+
+// expectAffected returns sql.ErrNoRows when a statement did not change any row.
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
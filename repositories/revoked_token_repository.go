@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RevokedTokenRepository stores access tokens that must be rejected before they expire.
+// An entry either names a single token by its jti, or revokes every token of a user
+// issued at or before revoked_before.
+type RevokedTokenRepository struct {
+	db *sql.DB
+}
+
+func NewRevokedTokenRepository(db *sql.DB) *RevokedTokenRepository {
+	return &RevokedTokenRepository{db: db}
+}
+
+// RevokeToken revokes a single access token until it expires.
+func (r *RevokedTokenRepository) RevokeToken(jti string, userID int64, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3) ON CONFLICT (jti) DO NOTHING`
+	_, err := r.db.Exec(query, jti, userID, expiresAt)
+	return err
+}
+
+// RevokeAllForUser revokes every access token of the user issued at or before the given time,
+// which is compared with the millisecond issued at claim of the tokens. The entry is kept until
+// expiresAt, after which all such tokens have expired anyway.
+func (r *RevokedTokenRepository) RevokeAllForUser(userID int64, before, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (user_id, revoked_before, expires_at) VALUES ($1, $2, $3)`
+	_, err := r.db.Exec(query, userID, before, expiresAt)
+	return err
+}
+
+// IsRevoked reports whether the access token identified by jti, issued to the user at issuedAt, has been revoked.
+func (r *RevokedTokenRepository) IsRevoked(jti string, userID int64, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	query := `SELECT EXISTS (
+		SELECT 1 FROM revoked_tokens
+		WHERE expires_at > NOW()
+		AND (jti = $1 OR (user_id = $2 AND revoked_before IS NOT NULL AND revoked_before >= $3))
+	)`
+	err := r.db.QueryRow(query, jti, userID, issuedAt).Scan(&revoked)
+	return revoked, err
+}
+
+// PurgeExpired deletes entries whose tokens can no longer be used and returns how many were removed.
+func (r *RevokedTokenRepository) PurgeExpired() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"api-server/models"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type RoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// GetAll retrieves every role together with the permissions it grants.
+func (r *RoleRepository) GetAll() ([]models.Role, error) {
+	query := `SELECT r.id, r.name, COALESCE(r.description, ''), array_remove(array_agg(p.name ORDER BY p.name), NULL)
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		GROUP BY r.id
+		ORDER BY r.name`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, (*pq.StringArray)(&role.Permissions)); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GetRoleByName retrieves a role by name.
+func (r *RoleRepository) GetRoleByName(name string) (*models.Role, error) {
+	var role models.Role
+	query := `SELECT id, name, COALESCE(description, '') FROM roles WHERE name = $1`
+	err := r.db.QueryRow(query, name).Scan(&role.ID, &role.Name, &role.Description)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetUserRoles retrieves the names of the roles assigned to a user.
+func (r *RoleRepository) GetUserRoles(userID int64) ([]string, error) {
+	query := `SELECT r.name FROM roles r JOIN user_roles ur ON ur.role_id = r.id WHERE ur.user_id = $1 ORDER BY r.name`
+	return r.queryNames(query, userID)
+}
+
+// GetUserPermissions retrieves the names of the permissions granted to a user through their roles.
+func (r *RoleRepository) GetUserPermissions(userID int64) ([]string, error) {
+	query := `SELECT DISTINCT p.name
+		FROM permissions p
+		JOIN role_permissions rp ON rp.permission_id = p.id
+		JOIN user_roles ur ON ur.role_id = rp.role_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name`
+	return r.queryNames(query, userID)
+}
+
+// AssignRole assigns a role to a user. Assigning a role the user already has is a no-op.
+func (r *RoleRepository) AssignRole(userID, roleID int64) error {
+	_, err := r.db.Exec(`INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, roleID)
+	return err
+}
+
+// RemoveRole removes a role from a user.
+func (r *RoleRepository) RemoveRole(userID, roleID int64) error {
+	_, err := r.db.Exec(`DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2`, userID, roleID)
+	return err
+}
+
+// queryNames runs a query returning a single text column.
+func (r *RoleRepository) queryNames(query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
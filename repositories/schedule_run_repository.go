@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ScheduleRunRepository records which scheduler instance claimed each run of a scheduled job.
+// A run is identified by the job name and the time it was scheduled for, so every instance
+// firing the same run contends for the same row and only the first one gets to execute it.
+type ScheduleRunRepository struct {
+	db *sql.DB
+}
+
+func NewScheduleRunRepository(db *sql.DB) *ScheduleRunRepository {
+	return &ScheduleRunRepository{db: db}
+}
+
+// Claim records the run of job scheduled for the given time as taken by holder. It reports
+// false if another instance claimed the run first.
+func (r *ScheduleRunRepository) Claim(job string, scheduledFor time.Time, holder string) (bool, error) {
+	query := `INSERT INTO schedule_runs (job, scheduled_for, claimed_by) VALUES ($1, $2, $3)
+		ON CONFLICT (job, scheduled_for) DO NOTHING`
+	result, err := r.db.Exec(query, job, scheduledFor, holder)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	return claimed == 1, err
+}
+
+// ClaimedBy returns the instance that claimed the run of job scheduled for the given time,
+// or an empty string if the run has not been claimed.
+func (r *ScheduleRunRepository) ClaimedBy(job string, scheduledFor time.Time) (string, error) {
+	var holder string
+	query := `SELECT claimed_by FROM schedule_runs WHERE job = $1 AND scheduled_for = $2`
+	err := r.db.QueryRow(query, job, scheduledFor).Scan(&holder)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return holder, err
+}
+
+// AbandonedRun is a claimed run of a job that never finished.
+type AbandonedRun struct {
+	ScheduledFor time.Time
+	// ClaimedBy is the instance that claimed the run before.
+	ClaimedBy string
+}
+
+// ReclaimUnfinished claims every unfinished run of job for holder and returns them with their
+// previous holders. Callers must hold the job's lock, so that no other instance is still running them.
+func (r *ScheduleRunRepository) ReclaimUnfinished(job, holder string) ([]AbandonedRun, error) {
+	query := `UPDATE schedule_runs AS r SET claimed_by = $2, claimed_at = NOW()
+		FROM (SELECT scheduled_for, claimed_by FROM schedule_runs WHERE job = $1 AND finished_at IS NULL FOR UPDATE) AS previous
+		WHERE r.job = $1 AND r.scheduled_for = previous.scheduled_for
+		RETURNING r.scheduled_for, previous.claimed_by`
+	rows, err := r.db.Query(query, job, holder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []AbandonedRun
+	for rows.Next() {
+		var run AbandonedRun
+		if err := rows.Scan(&run.ScheduledFor, &run.ClaimedBy); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Finish marks the run of job scheduled for the given time as finished.
+func (r *ScheduleRunRepository) Finish(job string, scheduledFor time.Time) error {
+	query := `UPDATE schedule_runs SET finished_at = NOW() WHERE job = $1 AND scheduled_for = $2`
+	_, err := r.db.Exec(query, job, scheduledFor)
+	return err
+}
+
+// PurgeClaimedBefore deletes the records of runs claimed before cutoff and returns how many were removed.
+func (r *ScheduleRunRepository) PurgeClaimedBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM schedule_runs WHERE claimed_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
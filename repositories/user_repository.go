@@ -3,6 +3,7 @@ package repositories
 import (
 	"api-server/models"
 	"database/sql"
+	"time"
 )
 
 type UserRepository struct {
@@ -23,7 +24,7 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 // GetUserByEmail retrieves a user by email.
 func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE email = $1 AND deleted_at IS NULL`
 	err := r.db.QueryRow(query, email).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, err
@@ -34,10 +35,55 @@ func (r *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 // GetUserByUsername retrieves a user by username.
 func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE username = $1`
+	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE username = $1 AND deleted_at IS NULL`
 	err := r.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
+
+// GetUserByID retrieves a user by ID.
+func (r *UserRepository) GetUserByID(id int64) (*models.User, error) {
+	var user models.User
+	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE id = $1 AND deleted_at IS NULL`
+	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser soft deletes a user; they can no longer log in or use existing tokens until restored.
+func (r *UserRepository) DeleteUser(id int64) error {
+	result, err := r.db.Exec(`UPDATE users SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// RestoreUser undoes the soft delete of a user.
+func (r *UserRepository) RestoreUser(id int64) (*models.User, error) {
+	var user models.User
+	query := `UPDATE users SET deleted_at = NULL, updated_at = NOW() WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id, username, email, created_at, updated_at`
+	err := r.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// PurgeDeletedUsers permanently deletes users soft deleted before the cutoff, together with
+// their sessions and role assignments, and returns how many were removed. Users who still own
+// items are kept, since deleting them would cascade to the items: their items have to be purged
+// first, once they are soft deleted and past the retention period themselves.
+func (r *UserRepository) PurgeDeletedUsers(cutoff time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE deleted_at < $1
+		AND NOT EXISTS (SELECT 1 FROM items WHERE items.owner_id = users.id)`
+	result, err := r.db.Exec(query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
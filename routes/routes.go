@@ -4,23 +4,53 @@ import (
 	"database/sql"
 	"net/http"
 
+	"api-server/config"
 	"api-server/controllers"
+	"api-server/middlewares"
+	"api-server/repositories"
+	"api-server/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, db *sql.DB) {
-	authController := controllers.NewAuthController(db)
+func SetupRoutes(router *gin.Engine, db *sql.DB, authConfig *config.AuthConfig) {
+	tokenService := services.NewTokenService(db, authConfig)
+	roleService := services.NewRoleService(db)
+	userRepo := repositories.NewUserRepository(db)
+
+	authController := controllers.NewAuthController(db, tokenService)
 	itemController := controllers.NewItemController(db)
+	adminController := controllers.NewAdminController(db)
+
+	// Middleware: Requires a valid bearer access token
+	authRequired := middlewares.AuthRequired(tokenService, userRepo, roleService)
 
 	// Routes: Auth
 	router.POST("/register", authController.Register)
 	router.POST("/login", authController.Login)
+	router.POST("/token/refresh", authController.Refresh)
+	router.POST("/logout", authRequired, authController.Logout)
+	router.POST("/logout/all", authRequired, authController.LogoutAll)
 
 	// Routes: Items
-	router.GET("/items", itemController.GetItems)
-	router.GET("/items/:id", itemController.GetItem)
-	router.POST("/items", itemController.CreateItem)
+	items := router.Group("/items", authRequired)
+	items.GET("", middlewares.RequirePermission("items:read"), itemController.GetItems)
+	items.GET("/search", middlewares.RequirePermission("items:read"), itemController.SearchItems)
+	items.GET("/:id", middlewares.RequirePermission("items:read"), itemController.GetItem)
+	items.POST("", middlewares.RequirePermission("items:write"), itemController.CreateItem)
+	items.PUT("/:id", middlewares.RequirePermission("items:write"), itemController.UpdateItem)
+	items.PATCH("/:id", middlewares.RequirePermission("items:write"), itemController.PatchItem)
+	items.DELETE("/:id", middlewares.RequirePermission("items:write"), itemController.DeleteItem)
+
+	// Routes: Admin
+	admin := router.Group("/admin", authRequired)
+	admin.GET("/roles", middlewares.RequirePermission("roles:read"), adminController.GetRoles)
+	admin.GET("/users/:id/roles", middlewares.RequirePermission("roles:read"), adminController.GetUserRoles)
+	admin.POST("/users/:id/roles", middlewares.RequirePermission("roles:assign"), adminController.AssignRole)
+	admin.DELETE("/users/:id/roles/:role", middlewares.RequirePermission("roles:assign"), adminController.RemoveRole)
+	admin.DELETE("/users/:id", middlewares.RequirePermission("users:delete"), adminController.DeleteUser)
+	admin.POST("/users/:id/restore", middlewares.RequirePermission("users:restore"), adminController.RestoreUser)
+	admin.POST("/items/:id/restore", middlewares.RequirePermission("items:restore"), adminController.RestoreItem)
 
 	// Routes: Custom 404 handler
 	router.NoRoute(func(c *gin.Context) {
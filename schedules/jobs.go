@@ -0,0 +1,19 @@
+package schedules
+
+// The built-in jobs and their default schedules. Use the configuration to change or disable them.
+func init() {
+	// Run ExampleTask every second where enabled; it only demonstrates a job
+	RegisterDisabled("example", "*/1 * * * * *", ExampleTask)
+
+	// Run DailyCleanupTask every hour
+	Register("daily_cleanup", "0 0 * * * *", DailyCleanupTask)
+
+	// Run PurgeRevokedTokensTask every 15 minutes
+	Register("purge_revoked_tokens", "0 */15 * * * *", PurgeRevokedTokensTask)
+
+	// Run PurgeRefreshTokensTask every hour at half past
+	Register("purge_refresh_tokens", "0 30 * * * *", PurgeRefreshTokensTask)
+
+	// Run PurgeScheduleRunsTask every hour
+	Register("purge_schedule_runs", "0 0 * * * *", PurgeScheduleRunsTask)
+}
@@ -0,0 +1,127 @@
+package schedules
+
+import (
+	"api-server/config"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	// Embed the time zone database, so time zones also load in images without one
+	_ "time/tzdata"
+)
+
+// TaskFunc is the work of a scheduled job.
+type TaskFunc func(db *sql.DB)
+
+// Job is a task registered under a name together with its default schedule.
+type Job struct {
+	Name string
+	Task TaskFunc
+	// Schedule is the default cron expression, including seconds, e.g. "0 0 0 * * *".
+	Schedule string
+	// Enabled is whether the job runs unless the configuration says otherwise.
+	Enabled bool
+}
+
+// ScheduledJob is a registered job with the configuration applied.
+type ScheduledJob struct {
+	Job
+	// TimeZone is the IANA time zone the schedule is evaluated in; empty for the host's local time.
+	TimeZone string
+}
+
+// Spec returns the cron specification of the job, including its time zone.
+func (j ScheduledJob) Spec() string {
+	if j.TimeZone == "" {
+		return j.Schedule
+	}
+	return "CRON_TZ=" + j.TimeZone + " " + j.Schedule
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Job)
+)
+
+// Register adds a job that runs task on schedule, enabled by default. Names are lower case
+// words separated by underscores, so they can be addressed from the environment. It panics if
+// the name is invalid or already registered.
+func Register(name, schedule string, task TaskFunc) {
+	register(Job{Name: name, Task: task, Schedule: schedule, Enabled: true})
+}
+
+// RegisterDisabled adds a job like Register, but it only runs when the configuration enables it.
+func RegisterDisabled(name, schedule string, task TaskFunc) {
+	register(Job{Name: name, Task: task, Schedule: schedule, Enabled: false})
+}
+
+func register(job Job) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if job.Name == "" || strings.Trim(job.Name, "abcdefghijklmnopqrstuvwxyz0123456789_") != "" {
+		panic(fmt.Sprintf("schedules: invalid job name %q", job.Name))
+	}
+	if _, exists := registry[job.Name]; exists {
+		panic(fmt.Sprintf("schedules: job %s registered twice", job.Name))
+	}
+	registry[job.Name] = job
+}
+
+// Jobs returns the registered jobs sorted by name.
+func Jobs() []Job {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	jobs := make([]Job, 0, len(registry))
+	for _, job := range registry {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+	return jobs
+}
+
+// Configure applies the configuration to the registered jobs and returns all of them, enabled or
+// not, sorted by name. It fails for configuration of unknown jobs and for unknown time zones.
+func Configure(cfg *config.SchedulesConfig) ([]ScheduledJob, error) {
+	jobs := Jobs()
+
+	known := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		known[job.Name] = true
+	}
+	for name := range cfg.Jobs {
+		if !known[name] {
+			return nil, fmt.Errorf("configuration for unknown job %q", name)
+		}
+	}
+
+	scheduled := make([]ScheduledJob, 0, len(jobs))
+	for _, job := range jobs {
+		s := ScheduledJob{Job: job, TimeZone: cfg.TimeZone}
+		if override, ok := cfg.Jobs[job.Name]; ok {
+			if override.Enabled != nil {
+				s.Enabled = *override.Enabled
+			}
+			if override.Schedule != "" {
+				s.Schedule = override.Schedule
+			}
+			if override.TimeZone != "" {
+				s.TimeZone = override.TimeZone
+			}
+		}
+
+		if s.TimeZone != "" {
+			if _, err := time.LoadLocation(s.TimeZone); err != nil {
+				return nil, fmt.Errorf("job %s: unknown time zone %q", job.Name, s.TimeZone)
+			}
+		}
+		scheduled = append(scheduled, s)
+	}
+	return scheduled, nil
+}
@@ -0,0 +1,144 @@
+package schedules
+
+import (
+	"api-server/config"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/robfig/cron/v3"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// findJob returns the configured job with the given name.
+func findJob(t *testing.T, jobs []ScheduledJob, name string) ScheduledJob {
+	t.Helper()
+	for _, job := range jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	t.Fatalf("job %s not found", name)
+	return ScheduledJob{}
+}
+
+func TestConfigureDefaults(t *testing.T) {
+	jobs, err := Configure(&config.SchedulesConfig{})
+	if err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+
+	registered := Jobs()
+	if len(jobs) != len(registered) {
+		t.Fatalf("Configure returned %d jobs, want %d", len(jobs), len(registered))
+	}
+	for i, job := range jobs {
+		if i > 0 && jobs[i-1].Name >= job.Name {
+			t.Errorf("jobs are not sorted by name: %s before %s", jobs[i-1].Name, job.Name)
+		}
+		want := registered[i]
+		if job.Name != want.Name || job.Schedule != want.Schedule || job.Enabled != want.Enabled || job.TimeZone != "" {
+			t.Errorf("job %s = %+v, want the registered defaults %+v", job.Name, job, want)
+		}
+	}
+}
+
+func TestConfigureOverrides(t *testing.T) {
+	jobs, err := Configure(&config.SchedulesConfig{
+		TimeZone: "Europe/Berlin",
+		Jobs: map[string]config.JobConfig{
+			"daily_cleanup":        {Enabled: boolPtr(false), Schedule: "0 30 2 * * *"},
+			"purge_revoked_tokens": {TimeZone: "UTC"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Configure returned error: %v", err)
+	}
+
+	cleanup := findJob(t, jobs, "daily_cleanup")
+	if cleanup.Enabled || cleanup.Schedule != "0 30 2 * * *" || cleanup.TimeZone != "Europe/Berlin" {
+		t.Errorf("daily_cleanup = %+v, want it disabled at 02:30 in Europe/Berlin", cleanup)
+	}
+	if spec := cleanup.Spec(); spec != "CRON_TZ=Europe/Berlin 0 30 2 * * *" {
+		t.Errorf("daily_cleanup spec = %q", spec)
+	}
+
+	purge := findJob(t, jobs, "purge_revoked_tokens")
+	if purge.TimeZone != "UTC" || purge.Schedule != "0 */15 * * * *" {
+		t.Errorf("purge_revoked_tokens = %+v, want its default schedule in UTC", purge)
+	}
+
+	// Every configured spec must be accepted by the scheduler
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	for _, job := range jobs {
+		if _, err := parser.Parse(job.Spec()); err != nil {
+			t.Errorf("job %s has invalid spec %q: %v", job.Name, job.Spec(), err)
+		}
+	}
+}
+
+func TestConfigureInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.SchedulesConfig
+		want string
+	}{
+		{
+			name: "unknown job",
+			cfg:  &config.SchedulesConfig{Jobs: map[string]config.JobConfig{"nightly_report": {}}},
+			want: `configuration for unknown job "nightly_report"`,
+		},
+		{
+			name: "unknown default time zone",
+			cfg:  &config.SchedulesConfig{TimeZone: "Mars/Olympus_Mons"},
+			want: `unknown time zone "Mars/Olympus_Mons"`,
+		},
+		{
+			name: "unknown job time zone",
+			cfg:  &config.SchedulesConfig{Jobs: map[string]config.JobConfig{"daily_cleanup": {TimeZone: "Nowhere"}}},
+			want: `job daily_cleanup: unknown time zone "Nowhere"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Configure(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Configure error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsInvalidNames(t *testing.T) {
+	for _, name := range []string{"", "Daily", "daily-cleanup", "daily cleanup", "daily_cleanup"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", name)
+				}
+			}()
+			Register(name, "* * * * * *", func(db *sql.DB) {})
+		}()
+	}
+}
+
+func TestBuiltInJobs(t *testing.T) {
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	registered := make(map[string]Job)
+	for _, job := range Jobs() {
+		registered[job.Name] = job
+		if _, err := parser.Parse(job.Schedule); err != nil {
+			t.Errorf("job %s has invalid schedule %q: %v", job.Name, job.Schedule, err)
+		}
+	}
+
+	for _, name := range []string{"daily_cleanup", "purge_revoked_tokens", "purge_refresh_tokens", "purge_schedule_runs"} {
+		if job, ok := registered[name]; !ok || !job.Enabled {
+			t.Errorf("built-in job %s is not registered and enabled by default", name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package schedules
+
+import (
+	"api-server/database"
+	"api-server/repositories"
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"time"
+)
+
+// Runner executes scheduled jobs so that every scheduled run happens once, even when several
+// scheduler instances share the database. Before running a job it takes a per-job PostgreSQL
+// advisory lock, so runs of the same job never overlap, and then claims the run in
+// schedule_runs, so instances firing the same run after the holder finished skip it.
+//
+// A run is marked finished once its task returns. A run left unfinished by an instance that
+// stopped mid-task is run again by the next instance that takes the job's lock, either when the
+// job fires or when an instance starts, so no run is lost. A run whose completion could not be
+// recorded is repeated the same way, so tasks should tolerate running twice.
+type Runner struct {
+	db   *sql.DB
+	runs *repositories.ScheduleRunRepository
+	// holder identifies this instance in schedule_runs and in pg_stat_activity.
+	holder string
+}
+
+func NewRunner(db *sql.DB) *Runner {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return &Runner{
+		db:     db,
+		runs:   repositories.NewScheduleRunRepository(db),
+		holder: fmt.Sprintf("%s pid %d", hostname, os.Getpid()),
+	}
+}
+
+// Run executes the run of job scheduled for the given time, unless another instance is
+// running the job or has already claimed that run. Skipped runs are logged with the holder.
+// Unfinished runs abandoned by other instances are caught up by the same execution.
+func (r *Runner) Run(job ScheduledJob, scheduledFor time.Time) {
+	// Every instance computes the same scheduled time, so it identifies the run across instances
+	scheduledFor = scheduledFor.UTC().Truncate(time.Second)
+	r.run(job, &scheduledFor)
+}
+
+// Recover runs the job once if an earlier run of it was abandoned unfinished, e.g. by an
+// instance that was killed mid-task. It is meant to be called when the scheduler starts.
+func (r *Runner) Recover(job ScheduledJob) {
+	r.run(job, nil)
+}
+
+// run executes the job under its lock for the run scheduled for the given time, if any, and for
+// any abandoned runs.
+func (r *Runner) run(job ScheduledJob, scheduledFor *time.Time) {
+	ctx := context.Background()
+
+	// Advisory locks belong to a session, so the lock is taken on a reserved connection
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		log.Printf("Error reserving connection for job %s: %v", job.Name, err)
+		return
+	}
+	defer conn.Close()
+
+	// Name the session after this instance, so the lock holder shows up in pg_stat_activity
+	application := "schedules " + r.holder
+	if len(application) > 63 {
+		application = application[:63]
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT set_config('application_name', $1, false)`, application); err != nil {
+		log.Printf("Error naming session for job %s: %v", job.Name, err)
+		return
+	}
+	defer conn.ExecContext(ctx, `RESET application_name`)
+
+	key := jobLockKey(job.Name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		log.Printf("Error taking lock for job %s: %v", job.Name, err)
+		return
+	}
+	if !locked {
+		if scheduledFor != nil {
+			log.Printf("Skipping job %s run scheduled for %s: still running in %s", job.Name, scheduledFor.Format(time.RFC3339), database.DescribeLockHolder(conn, key))
+		}
+		return
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			log.Printf("Error releasing lock for job %s: %v", job.Name, err)
+		}
+	}()
+
+	// With the lock held no other instance is running the job, so unfinished runs were abandoned
+	var runs []time.Time
+	abandoned, err := r.runs.ReclaimUnfinished(job.Name, r.holder)
+	if err != nil {
+		log.Printf("Error reclaiming abandoned runs of job %s: %v", job.Name, err)
+		return
+	}
+	for _, run := range abandoned {
+		log.Printf("Job %s run scheduled for %s was abandoned by %s; running it again", job.Name, run.ScheduledFor.Format(time.RFC3339), run.ClaimedBy)
+		runs = append(runs, run.ScheduledFor)
+	}
+
+	if scheduledFor != nil {
+		claimed, err := r.runs.Claim(job.Name, *scheduledFor, r.holder)
+		if err != nil {
+			log.Printf("Error claiming job %s run scheduled for %s: %v", job.Name, scheduledFor.Format(time.RFC3339), err)
+			return
+		}
+		if claimed {
+			runs = append(runs, *scheduledFor)
+		} else if len(runs) == 0 {
+			holder, err := r.runs.ClaimedBy(job.Name, *scheduledFor)
+			if err != nil || holder == "" {
+				holder = "another instance"
+			}
+			log.Printf("Skipping job %s run scheduled for %s: already claimed by %s", job.Name, scheduledFor.Format(time.RFC3339), holder)
+			return
+		}
+	}
+	if len(runs) == 0 {
+		return
+	}
+
+	// One execution catches up on every claimed run
+	job.Task(r.db)
+
+	for _, run := range runs {
+		if err := r.runs.Finish(job.Name, run); err != nil {
+			log.Printf("Error recording job %s run scheduled for %s as finished: %v", job.Name, run.Format(time.RFC3339), err)
+		}
+	}
+}
+
+// jobLockKey derives the advisory lock key of a job from its name.
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("schedules:" + name))
+	return int64(h.Sum64())
+}
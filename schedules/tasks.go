@@ -1,12 +1,14 @@
 package schedules
 
 import (
+	"api-server/config"
+	"api-server/repositories"
 	"database/sql"
 	"log"
 	"time"
 )
 
-// Task: Example task that runs every minute
+// Task: Example task that runs every second when enabled
 func ExampleTask(db *sql.DB) {
 	log.Println("Running cron task: Current time:", time.Now())
 	performDatabaseTask(db)
@@ -25,6 +27,49 @@ func DailyCleanupTask(db *sql.DB) {
 	}
 }
 
+// Task: Purge revocation entries for access tokens that have expired
+func PurgeRevokedTokensTask(db *sql.DB) {
+	log.Println("Running revoked tokens purge task")
+
+	purged, err := repositories.NewRevokedTokenRepository(db).PurgeExpired()
+	if err != nil {
+		log.Printf("Error purging revoked tokens: %v", err)
+		return
+	}
+	log.Printf("Purged %d expired revoked token entries", purged)
+}
+
+// Task: Purge refresh tokens that have expired
+func PurgeRefreshTokensTask(db *sql.DB) {
+	log.Println("Running refresh tokens purge task")
+
+	purged, err := repositories.NewRefreshTokenRepository(db).PurgeExpired()
+	if err != nil {
+		log.Printf("Error purging refresh tokens: %v", err)
+		return
+	}
+	log.Printf("Purged %d expired refresh tokens", purged)
+}
+
+// Task: Purge the records of claimed schedule runs older than the retention period
+func PurgeScheduleRunsTask(db *sql.DB) {
+	log.Println("Running schedule runs purge task")
+
+	retention, err := config.LoadScheduleRunRetention()
+	if err != nil {
+		log.Printf("Error purging schedule runs: %v", err)
+		return
+	}
+	cutoff := time.Now().Add(-retention)
+
+	purged, err := repositories.NewScheduleRunRepository(db).PurgeClaimedBefore(cutoff)
+	if err != nil {
+		log.Printf("Error purging schedule runs: %v", err)
+		return
+	}
+	log.Printf("Purged %d schedule runs claimed before %s", purged, cutoff.Format(time.RFC3339))
+}
+
 // Helper function: Database interaction for task
 func performDatabaseTask(db *sql.DB) {
 	var result string
@@ -36,11 +81,24 @@ func performDatabaseTask(db *sql.DB) {
 	log.Println("Database result:", result)
 }
 
-// Helper function: Cleanup old records
+// Helper function: Permanently delete rows that were soft deleted longer ago than the retention period
 func cleanupOldRecords(db *sql.DB) error {
-	_, err := db.Exec("DELETE FROM some_table WHERE created_at < NOW() - INTERVAL '30 days'")
+	retention, err := config.LoadSoftDeleteRetention()
 	if err != nil {
 		return err
 	}
+	cutoff := time.Now().Add(-retention)
+
+	// Items go first, so users whose remaining items were all purged can be purged as well
+	items, err := repositories.NewItemRepository(db).PurgeDeleted(cutoff)
+	if err != nil {
+		return err
+	}
+	users, err := repositories.NewUserRepository(db).PurgeDeletedUsers(cutoff)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Purged %d items and %d users deleted before %s", items, users, cutoff.Format(time.RFC3339))
 	return nil
 }
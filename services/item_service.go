@@ -2,11 +2,30 @@ package services
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
 
 	"api-server/models"
 	"api-server/repositories"
+	"api-server/utils"
 )
 
+var (
+	// ErrItemNotFound is returned when an item does not exist or is not visible to the user.
+	ErrItemNotFound = errors.New("item not found")
+	// ErrInvalidPatch is returned when a merge patch document is not valid JSON.
+	ErrInvalidPatch = errors.New("invalid merge patch document")
+	// ErrVersionMismatch is returned when an item changed after the caller read it.
+	ErrVersionMismatch = errors.New("item has been modified")
+	// ErrInvalidSort is returned when a list is ordered by a field that is not whitelisted.
+	ErrInvalidSort = errors.New("invalid sort field")
+)
+
+// DefaultPageSize is the number of results returned when no limit is requested.
+const DefaultPageSize = 20
+
 type ItemService struct {
 	repo *repositories.ItemRepository
 }
@@ -17,14 +36,274 @@ func NewItemService(db *sql.DB) *ItemService {
 	}
 }
 
-func (s *ItemService) GetAllItems() ([]models.Item, error) {
-	return s.repo.GetAll()
+// ListItemsParams are the list options accepted from clients.
+// Sort is a field name optionally prefixed with "-" for descending order.
+type ListItemsParams struct {
+	Limit        int
+	Offset       int
+	Cursor       string
+	Sort         string
+	Name         string
+	NamePrefix   string
+	NameContains string
+}
+
+// ItemPage is a page of items with its pagination metadata.
+type ItemPage struct {
+	Items      []models.Item     `json:"data"`
+	Pagination models.Pagination `json:"pagination"`
+}
+
+// ListItems returns a page of the items visible to the user, using keyset pagination when a cursor is given
+// and limit/offset pagination otherwise.
+func (s *ItemService) ListItems(user *models.User, params ListItemsParams) (*ItemPage, error) {
+	opts := repositories.ItemListOptions{
+		Filter: repositories.ItemFilter{
+			OwnerID:      ownerScope(user),
+			Name:         params.Name,
+			NamePrefix:   params.NamePrefix,
+			NameContains: params.NameContains,
+		},
+		SortField: strings.TrimPrefix(params.Sort, "-"),
+		SortDesc:  strings.HasPrefix(params.Sort, "-"),
+		Limit:     params.Limit,
+		Offset:    params.Offset,
+	}
+	if opts.SortField == "" {
+		opts.SortField = "id"
+	}
+	if !repositories.ItemSortFields[opts.SortField] {
+		return nil, ErrInvalidSort
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+
+	// Normalized ordering, e.g. "id" or "-name", recorded in cursors
+	sort := opts.SortField
+	if opts.SortDesc {
+		sort = "-" + sort
+	}
+
+	if params.Cursor != "" {
+		var after repositories.ItemCursor
+		if err := utils.DecodeCursor(params.Cursor, &after); err != nil {
+			return nil, err
+		}
+		// A cursor only describes a position within the ordering it was created for
+		if after.Sort != sort {
+			return nil, utils.ErrInvalidCursor
+		}
+		opts.After = &after
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	limit := opts.Limit
+	opts.Limit++
+	items, err := s.repo.List(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ItemPage{Pagination: models.Pagination{Limit: limit}}
+	if len(items) > limit {
+		items = items[:limit]
+		page.Pagination.HasMore = true
+
+		last := items[len(items)-1]
+		lastID, err := strconv.ParseInt(last.ID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		next, err := utils.EncodeCursor(repositories.ItemCursor{Sort: sort, Name: last.Name, ID: lastID})
+		if err != nil {
+			return nil, err
+		}
+		page.Pagination.NextCursor = next
+	}
+	page.Items = items
+
+	if opts.After == nil {
+		total, err := s.repo.Count(opts.Filter)
+		if err != nil {
+			return nil, err
+		}
+		offset := opts.Offset
+		page.Pagination.Offset = &offset
+		page.Pagination.Total = &total
+	}
+
+	return page, nil
+}
+
+// SearchItemsParams are the search options accepted from clients.
+type SearchItemsParams struct {
+	Query  string
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// ItemSearchPage is a page of search results with its pagination metadata.
+type ItemSearchPage struct {
+	Results    []models.ItemSearchResult `json:"data"`
+	Pagination models.Pagination         `json:"pagination"`
+}
+
+// SearchItems runs a ranked full-text search over the items visible to the user.
+// It follows the same pagination contract as ListItems.
+func (s *ItemService) SearchItems(user *models.User, params SearchItemsParams) (*ItemSearchPage, error) {
+	opts := repositories.ItemSearchOptions{
+		Query:   params.Query,
+		OwnerID: ownerScope(user),
+		Limit:   params.Limit,
+		Offset:  params.Offset,
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+
+	if params.Cursor != "" {
+		var after repositories.ItemSearchCursor
+		if err := utils.DecodeCursor(params.Cursor, &after); err != nil {
+			return nil, err
+		}
+		// A cursor only describes a position within the results of the query it was created for
+		if after.Query != params.Query {
+			return nil, utils.ErrInvalidCursor
+		}
+		opts.After = &after
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	limit := opts.Limit
+	opts.Limit++
+	results, err := s.repo.Search(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ItemSearchPage{Pagination: models.Pagination{Limit: limit}}
+	if len(results) > limit {
+		results = results[:limit]
+		page.Pagination.HasMore = true
+
+		last := results[len(results)-1]
+		lastID, err := strconv.ParseInt(last.ID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		next, err := utils.EncodeCursor(repositories.ItemSearchCursor{Query: params.Query, Rank: last.Rank, ID: lastID})
+		if err != nil {
+			return nil, err
+		}
+		page.Pagination.NextCursor = next
+	}
+	page.Results = results
+
+	if opts.After == nil {
+		total, err := s.repo.CountSearch(opts.Query, opts.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+		offset := opts.Offset
+		page.Pagination.Offset = &offset
+		page.Pagination.Total = &total
+	}
+
+	return page, nil
+}
+
+func (s *ItemService) GetItemByID(user *models.User, id string) (*models.Item, error) {
+	if !validItemID(id) {
+		return nil, ErrItemNotFound
+	}
+
+	item, err := s.repo.GetByID(id, ownerScope(user))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	return item, err
+}
+
+func (s *ItemService) CreateItem(user *models.User, name string) (*models.Item, error) {
+	return s.repo.Create(name, user.ID)
+}
+
+// UpdateItem renames an item, provided it is still at the version the caller last read.
+func (s *ItemService) UpdateItem(user *models.User, id string, version int64, name string) (*models.Item, error) {
+	if !validItemID(id) {
+		return nil, ErrItemNotFound
+	}
+
+	item, err := s.repo.Update(id, ownerScope(user), version, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.conflictOrNotFound(user, id)
+	}
+	return item, err
+}
+
+// MergeItemPatch applies a JSON Merge Patch to the editable fields of the item and
+// returns the resulting document, which the caller validates before calling UpdateItem.
+func (s *ItemService) MergeItemPatch(item *models.Item, patch []byte) ([]byte, error) {
+	original, err := json.Marshal(map[string]interface{}{"name": item.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := utils.MergePatch(original, patch)
+	if err != nil {
+		return nil, ErrInvalidPatch
+	}
+	return patched, nil
+}
+
+// DeleteItem soft deletes an item, provided it is still at the version the caller last read.
+func (s *ItemService) DeleteItem(user *models.User, id string, version int64) error {
+	if !validItemID(id) {
+		return ErrItemNotFound
+	}
+
+	err := s.repo.Delete(id, ownerScope(user), version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.conflictOrNotFound(user, id)
+	}
+	return err
+}
+
+// RestoreItem undoes the soft delete of an item.
+func (s *ItemService) RestoreItem(id string) (*models.Item, error) {
+	if !validItemID(id) {
+		return nil, ErrItemNotFound
+	}
+
+	item, err := s.repo.Restore(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrItemNotFound
+	}
+	return item, err
 }
 
-func (s *ItemService) GetItemByID(id string) (*models.Item, error) {
-	return s.repo.GetByID(id)
+// conflictOrNotFound explains why a versioned write matched no row: the item either
+// changed since it was read or no longer exists for the user.
+func (s *ItemService) conflictOrNotFound(user *models.User, id string) error {
+	_, err := s.GetItemByID(user, id)
+	if err != nil {
+		return err
+	}
+	return ErrVersionMismatch
+}
+
+// ownerScope limits regular users to their own items; admins can access every item.
+func ownerScope(user *models.User) int64 {
+	if user.HasRole(models.RoleAdmin) {
+		return repositories.AnyOwner
+	}
+	return user.ID
 }
 
-func (s *ItemService) CreateItem(name string) (*models.Item, error) {
-	return s.repo.Create(name)
+// validItemID reports whether id can identify an item, so malformed IDs are treated as not found.
+func validItemID(id string) bool {
+	n, err := strconv.ParseInt(id, 10, 32)
+	return err == nil && n > 0
 }
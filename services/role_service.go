@@ -0,0 +1,103 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+
+	"api-server/models"
+	"api-server/repositories"
+)
+
+var (
+	// ErrUserNotFound is returned when the target user does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrRoleNotFound is returned when the requested role does not exist.
+	ErrRoleNotFound = errors.New("role not found")
+)
+
+type RoleService struct {
+	roleRepo *repositories.RoleRepository
+	userRepo *repositories.UserRepository
+}
+
+func NewRoleService(db *sql.DB) *RoleService {
+	return &RoleService{
+		roleRepo: repositories.NewRoleRepository(db),
+		userRepo: repositories.NewUserRepository(db),
+	}
+}
+
+// GetAllRoles returns every role with its permissions.
+func (s *RoleService) GetAllRoles() ([]models.Role, error) {
+	return s.roleRepo.GetAll()
+}
+
+// GetUserRoles returns the roles assigned to a user.
+func (s *RoleService) GetUserRoles(userID int64) ([]string, error) {
+	if _, err := s.findUser(userID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.GetUserRoles(userID)
+}
+
+// AssignRole assigns a role to a user and returns the user's roles afterwards.
+func (s *RoleService) AssignRole(userID int64, roleName string) ([]string, error) {
+	user, role, err := s.findUserAndRole(userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.roleRepo.AssignRole(user.ID, role.ID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.GetUserRoles(user.ID)
+}
+
+// RemoveRole removes a role from a user and returns the user's roles afterwards.
+func (s *RoleService) RemoveRole(userID int64, roleName string) ([]string, error) {
+	user, role, err := s.findUserAndRole(userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.roleRepo.RemoveRole(user.ID, role.ID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.GetUserRoles(user.ID)
+}
+
+// LoadAuthorization populates the roles and permissions of a user.
+func (s *RoleService) LoadAuthorization(user *models.User) error {
+	roles, err := s.roleRepo.GetUserRoles(user.ID)
+	if err != nil {
+		return err
+	}
+	permissions, err := s.roleRepo.GetUserPermissions(user.ID)
+	if err != nil {
+		return err
+	}
+	user.Roles = roles
+	user.Permissions = permissions
+	return nil
+}
+
+func (s *RoleService) findUser(userID int64) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (s *RoleService) findUserAndRole(userID int64, roleName string) (*models.User, *models.Role, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	role, err := s.roleRepo.GetRoleByName(roleName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, role, nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"api-server/config"
+	"api-server/models"
+	"api-server/repositories"
+	"api-server/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidToken is returned when an access or refresh token cannot be verified.
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrTokenReused is returned when an already rotated refresh token is presented again.
+	ErrTokenReused = errors.New("refresh token reuse detected, session revoked")
+)
+
+// issuedAtPrecision is the precision of the issue time of access tokens, so a logout revokes
+// exactly the tokens issued up to it rather than up to the start of its second.
+const issuedAtPrecision = time.Millisecond
+
+func init() {
+	// Time claims are parsed through a float64 and then truncated to TimePrecision; truncating
+	// to microseconds keeps the millisecond of the issue time intact
+	jwt.TimePrecision = time.Microsecond
+}
+
+// AccessClaims are the claims carried by an access token.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	// SessionID is the refresh token family the access token was issued for.
+	SessionID string `json:"sid,omitempty"`
+}
+
+// UserID returns the ID of the user the token was issued to.
+func (c *AccessClaims) UserID() (int64, error) {
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+// issuedAt returns the issue time of the token. The parsed claim can fall short of the issued
+// value by a microsecond; rounding restores the millisecond the token was issued at.
+func (c *AccessClaims) issuedAt() time.Time {
+	return c.IssuedAt.Time.Round(issuedAtPrecision)
+}
+
+// TokenPair is an access token together with the refresh token used to renew it.
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
+
+type TokenService struct {
+	cfg           *config.AuthConfig
+	userRepo      *repositories.UserRepository
+	refreshTokens *repositories.RefreshTokenRepository
+	revokedTokens *repositories.RevokedTokenRepository
+}
+
+func NewTokenService(db *sql.DB, cfg *config.AuthConfig) *TokenService {
+	return &TokenService{
+		cfg:           cfg,
+		userRepo:      repositories.NewUserRepository(db),
+		refreshTokens: repositories.NewRefreshTokenRepository(db),
+		revokedTokens: repositories.NewRevokedTokenRepository(db),
+	}
+}
+
+// IssueTokenPair starts a new session for the user on the given device.
+func (s *TokenService) IssueTokenPair(user *models.User, device string) (*TokenPair, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokenPair(user, familyID, device)
+}
+
+// Refresh rotates a refresh token: the presented token is consumed and a new pair is issued in the same family.
+// Presenting a token that was already consumed revokes the whole family.
+func (s *TokenService) Refresh(refreshToken, device string) (*TokenPair, error) {
+	stored, err := s.refreshTokens.GetByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if stored.UsedAt != nil {
+		return nil, s.revokeReusedFamily(stored)
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	// Consume the token; losing this race means another request rotated it first
+	consumed, err := s.refreshTokens.MarkUsed(stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, s.revokeReusedFamily(stored)
+	}
+
+	user, err := s.userRepo.GetUserByID(stored.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if device == "" {
+		device = stored.Device
+	}
+
+	return s.issueTokenPair(user, stored.FamilyID, device)
+}
+
+// IssueAccessToken signs a new access token for the user and returns it with its expiry time.
+func (s *TokenService) IssueAccessToken(user *models.User, sessionID string) (string, time.Time, error) {
+	jti, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now().Truncate(issuedAtPrecision)
+	expiresAt := now.Add(s.cfg.AccessTokenTTL)
+
+	claims := AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			Issuer:    s.cfg.Issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		SessionID: sessionID,
+	}
+	if s.cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.cfg.Audience}
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(s.cfg.Algorithm), claims)
+	signed, err := token.SignedString(s.cfg.SigningKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+// ParseAccessToken verifies the signature and registered claims of an access token.
+func (s *TokenService) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{s.cfg.Algorithm}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if s.cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(s.cfg.Issuer))
+	}
+	if s.cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(s.cfg.Audience))
+	}
+
+	claims := &AccessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.cfg.SigningKey, nil
+	}, options...)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// Logout ends the session of the given access token: the token itself is revoked
+// and the refresh token family it was issued with can no longer be used.
+func (s *TokenService) Logout(claims *AccessClaims) error {
+	userID, err := claims.UserID()
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	if err := s.revokedTokens.RevokeToken(claims.ID, userID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if claims.SessionID != "" {
+		return s.refreshTokens.RevokeFamily(claims.SessionID)
+	}
+	return nil
+}
+
+// LogoutAll ends every session of the user: all access tokens issued so far are revoked
+// together with all of the user's refresh tokens.
+func (s *TokenService) LogoutAll(userID int64) error {
+	now := time.Now()
+	// Every token issued up to and including the current millisecond is revoked
+	if err := s.revokedTokens.RevokeAllForUser(userID, now.Truncate(issuedAtPrecision), now.Add(s.cfg.AccessTokenTTL)); err != nil {
+		return err
+	}
+	return s.refreshTokens.RevokeAllForUser(userID)
+}
+
+// IsRevoked reports whether the access token has been revoked by a logout.
+func (s *TokenService) IsRevoked(claims *AccessClaims) (bool, error) {
+	userID, err := claims.UserID()
+	if err != nil || claims.IssuedAt == nil {
+		return true, ErrInvalidToken
+	}
+	return s.revokedTokens.IsRevoked(claims.ID, userID, claims.issuedAt())
+}
+
+// issueTokenPair signs an access token and stores a fresh refresh token in the given family.
+func (s *TokenService) issueTokenPair(user *models.User, familyID, device string) (*TokenPair, error) {
+	accessToken, accessExpiresAt, err := s.IssueAccessToken(user, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		Device:    device,
+		ExpiresAt: time.Now().Add(s.cfg.RefreshTokenTTL),
+	}
+	if err := s.refreshTokens.Create(stored); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// revokeReusedFamily revokes the family of a refresh token that was presented after being rotated.
+func (s *TokenService) revokeReusedFamily(token *models.RefreshToken) error {
+	if err := s.refreshTokens.RevokeFamily(token.FamilyID); err != nil {
+		return err
+	}
+	return ErrTokenReused
+}
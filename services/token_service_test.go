@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"api-server/config"
+	"api-server/models"
+)
+
+func TestAccessTokenIssuedAtPrecision(t *testing.T) {
+	s := NewTokenService(nil, &config.AuthConfig{
+		SigningKey:     []byte("test-secret"),
+		Algorithm:      "HS256",
+		Issuer:         "api-server",
+		AccessTokenTTL: time.Minute,
+	})
+	user := &models.User{ID: 1}
+
+	for i := 0; i < 100; i++ {
+		before := time.Now().Truncate(time.Millisecond)
+		token, _, err := s.IssueAccessToken(user, "session")
+		if err != nil {
+			t.Fatalf("IssueAccessToken: %v", err)
+		}
+		after := time.Now()
+
+		claims, err := s.ParseAccessToken(token)
+		if err != nil {
+			t.Fatalf("ParseAccessToken: %v", err)
+		}
+		issuedAt := claims.issuedAt()
+		if !issuedAt.Equal(issuedAt.Truncate(time.Millisecond)) {
+			t.Fatalf("issued at %s is not a whole millisecond", issuedAt.Format(time.RFC3339Nano))
+		}
+		if issuedAt.Before(before) || issuedAt.After(after) {
+			t.Fatalf("issued at %s, want between %s and %s", issuedAt.Format(time.RFC3339Nano), before.Format(time.RFC3339Nano), after.Format(time.RFC3339Nano))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+
+	"api-server/models"
+	"api-server/repositories"
+)
+
+type UserService struct {
+	repo *repositories.UserRepository
+}
+
+func NewUserService(db *sql.DB) *UserService {
+	return &UserService{
+		repo: repositories.NewUserRepository(db),
+	}
+}
+
+// DeleteUser soft deletes a user.
+func (s *UserService) DeleteUser(id int64) error {
+	err := s.repo.DeleteUser(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// RestoreUser undoes the soft delete of a user.
+func (s *UserService) RestoreUser(id int64) (*models.User, error) {
+	user, err := s.repo.RestoreUser(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
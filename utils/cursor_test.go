@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testCursor struct {
+	Sort string `json:"s"`
+	Name string `json:"n,omitempty"`
+	ID   int64  `json:"i"`
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []testCursor{
+		{Sort: "id", ID: 1},
+		{Sort: "-name", Name: "Spaces and ünïcode/+?&=", ID: 42},
+		{Sort: "created_at", Name: "", ID: 9007199254740993},
+	}
+
+	for _, want := range tests {
+		cursor, err := EncodeCursor(want)
+		if err != nil {
+			t.Fatalf("EncodeCursor(%+v) returned error: %v", want, err)
+		}
+		if strings.ContainsAny(cursor, "+/=") {
+			t.Errorf("EncodeCursor(%+v) = %q, which is not URL-safe", want, cursor)
+		}
+
+		var got testCursor
+		if err := DecodeCursor(cursor, &got); err != nil {
+			t.Fatalf("DecodeCursor(%q) returned error: %v", cursor, err)
+		}
+		if got != want {
+			t.Errorf("DecodeCursor(EncodeCursor(%+v)) = %+v", want, got)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name, cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "not a cursor!"},
+		{"standard base64 padding", "eyJpIjoxfQ=="},
+		{"not JSON", "bm90IGpzb24"},
+		{"wrong type", "eyJpIjoib25lIn0"}, // {"i":"one"}
+	}
+
+	for _, tt := range tests {
+		var position testCursor
+		if err := DecodeCursor(tt.cursor, &position); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: DecodeCursor(%q) = %v, want ErrInvalidCursor", tt.name, tt.cursor, err)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatETag returns the strong entity tag of a resource version.
+func FormatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// MatchETag reports whether an If-Match or If-None-Match header value matches etag.
+// If-Match uses the strong comparison, under which weak tags never match;
+// If-None-Match uses the weak comparison, which ignores the W/ prefix (RFC 9110, section 8.8.3.2).
+func MatchETag(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package utils
+
+import "testing"
+
+func TestFormatETag(t *testing.T) {
+	if got := FormatETag(3); got != `"3"` {
+		t.Errorf(`FormatETag(3) = %s, want "3"`, got)
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		header, etag string
+		weak, want   bool
+	}{
+		// If-Match: strong comparison
+		{`"3"`, `"3"`, false, true},
+		{`"2"`, `"3"`, false, false},
+		{`*`, `"3"`, false, true},
+		{`"1", "3"`, `"3"`, false, true},
+		{`"1","2"`, `"3"`, false, false},
+		{`W/"3"`, `"3"`, false, false},
+		{`"3"`, `W/"3"`, false, false},
+		{`3`, `"3"`, false, false},
+		{``, `"3"`, false, false},
+
+		// If-None-Match: weak comparison
+		{`"3"`, `"3"`, true, true},
+		{`W/"3"`, `"3"`, true, true},
+		{`"3"`, `W/"3"`, true, true},
+		{`W/"2", W/"3"`, `"3"`, true, true},
+		{`W/"2"`, `"3"`, true, false},
+		{`*`, `"3"`, true, true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchETag(tt.header, tt.etag, tt.weak); got != tt.want {
+			t.Errorf("MatchETag(%q, %q, %v) = %v, want %v", tt.header, tt.etag, tt.weak, got, tt.want)
+		}
+	}
+}
@@ -11,4 +11,16 @@ type RegisterUserValidator struct {
 type LoginUserValidator struct {
 	Email    string `json:"email" binding:"required,email" message:"Email is required and must be a valid email address"`
 	Password string `json:"password" binding:"required,min=6" message:"Password is required and must be at least 6 characters long"`
+	Device   string `json:"device" binding:"omitempty,max=255" message:"Device must be at most 255 characters long"`
+}
+
+// RefreshTokenValidator holds the fields for validating token refresh input.
+type RefreshTokenValidator struct {
+	RefreshToken string `json:"refresh_token" binding:"required" message:"Refresh token is required"`
+	Device       string `json:"device" binding:"omitempty,max=255" message:"Device must be at most 255 characters long"`
+}
+
+// AssignRoleValidator holds the fields for validating role assignment input.
+type AssignRoleValidator struct {
+	Role string `json:"role" binding:"required,max=50" message:"Role is required and must be at most 50 characters long"`
 }
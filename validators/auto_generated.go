@@ -0,0 +1,16 @@
+// Code generated by go generate; DO NOT EDIT.
+package validators
+
+import "reflect"
+
+func init() {
+	RegisterValidator(reflect.TypeOf(RegisterUserValidator{}).Name(), RegisterUserValidator{})
+	RegisterValidator(reflect.TypeOf(LoginUserValidator{}).Name(), LoginUserValidator{})
+	RegisterValidator(reflect.TypeOf(RefreshTokenValidator{}).Name(), RefreshTokenValidator{})
+	RegisterValidator(reflect.TypeOf(AssignRoleValidator{}).Name(), AssignRoleValidator{})
+	RegisterValidator(reflect.TypeOf(CreateItemInput{}).Name(), CreateItemInput{})
+	RegisterValidator(reflect.TypeOf(UpdateItemInput{}).Name(), UpdateItemInput{})
+	RegisterValidator(reflect.TypeOf(PatchItemInput{}).Name(), PatchItemInput{})
+	RegisterValidator(reflect.TypeOf(ListItemsQuery{}).Name(), ListItemsQuery{})
+	RegisterValidator(reflect.TypeOf(SearchItemsQuery{}).Name(), SearchItemsQuery{})
+}
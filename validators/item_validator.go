@@ -1,5 +1,35 @@
 package validators
 
+// CreateItemInput holds the fields for validating item creation input.
 type CreateItemInput struct {
-	Name string `json:"name" binding:"required,min=1,max=100"`
+	Name string `json:"name" binding:"required,min=1,max=100" message:"Name is required and must be between 1 and 100 characters"`
+}
+
+// UpdateItemInput holds the fields for validating full item replacement (PUT).
+type UpdateItemInput struct {
+	Name string `json:"name" binding:"required,min=1,max=100" message:"Name is required and must be between 1 and 100 characters"`
+}
+
+// PatchItemInput holds the fields of an item after a JSON Merge Patch has been applied (PATCH).
+type PatchItemInput struct {
+	Name string `json:"name" binding:"required,min=1,max=100" message:"Name cannot be removed and must be between 1 and 100 characters"`
+}
+
+// ListItemsQuery holds the query parameters for listing items.
+type ListItemsQuery struct {
+	Limit        int    `form:"limit" binding:"omitempty,min=1,max=100" message:"Limit must be between 1 and 100"`
+	Offset       int    `form:"offset" binding:"omitempty,min=0,excluded_with=Cursor" message:"Offset must be a non-negative number and cannot be combined with cursor"`
+	Cursor       string `form:"cursor" binding:"omitempty,max=1024" message:"Cursor must be a value returned by a previous page"`
+	Sort         string `form:"sort" binding:"omitempty,oneof=id -id name -name" message:"Sort must be one of id, -id, name or -name"`
+	Name         string `form:"name" binding:"omitempty,max=100" message:"Name must be at most 100 characters"`
+	NamePrefix   string `form:"name_prefix" binding:"omitempty,max=100" message:"Name prefix must be at most 100 characters"`
+	NameContains string `form:"name_contains" binding:"omitempty,max=100" message:"Name contains must be at most 100 characters"`
+}
+
+// SearchItemsQuery holds the query parameters for searching items.
+type SearchItemsQuery struct {
+	Query  string `form:"q" binding:"required,max=200" message:"Search query is required and must be at most 200 characters"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100" message:"Limit must be between 1 and 100"`
+	Offset int    `form:"offset" binding:"omitempty,min=0,excluded_with=Cursor" message:"Offset must be a non-negative number and cannot be combined with cursor"`
+	Cursor string `form:"cursor" binding:"omitempty,max=1024" message:"Cursor must be a value returned by a previous page"`
 }